@@ -0,0 +1,269 @@
+package krot
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKeyCacheSize is the default number of keys held by a cached
+	// KeyStorage.
+	DefaultKeyCacheSize = 1024
+
+	// DefaultKeyCacheTTL is the default duration keys are held by a cached
+	// KeyStorage.
+	DefaultKeyCacheTTL = time.Minute
+
+	// DefaultKeyCacheNegativeTTL is the default duration a cached KeyStorage
+	// remembers that a key was not found.
+	DefaultKeyCacheNegativeTTL = 5 * time.Second
+)
+
+// KeyCacheSettings configures a cached KeyStorage.
+type KeyCacheSettings struct {
+	// Size is the maximum number of entries in the cache. When it is full, the
+	// least recently used entry is evicted.
+	// Values less than or equal to 0 use DefaultKeyCacheSize.
+	Size int
+
+	// TTL is the duration keys are served from the cache before being read
+	// from the underlying storage again. Keys are never cached beyond their
+	// expiration.
+	// Values less than or equal to 0 use DefaultKeyCacheTTL.
+	TTL time.Duration
+
+	// NegativeTTL is the duration the cache remembers that a key was not found
+	// in the underlying storage.
+	// Values less than or equal to 0 disable negative caching.
+	NegativeTTL time.Duration
+}
+
+// DefaultKeyCacheSettings returns the default settings of a cached KeyStorage.
+func DefaultKeyCacheSettings() *KeyCacheSettings {
+	return &KeyCacheSettings{
+		Size:        DefaultKeyCacheSize,
+		TTL:         DefaultKeyCacheTTL,
+		NegativeTTL: DefaultKeyCacheNegativeTTL,
+	}
+}
+
+type cacheEntry struct {
+	id      string
+	key     *Key // nil if the key was not found.
+	expires time.Time
+}
+
+type cachedStorage struct {
+	storage  KeyStorage
+	settings KeyCacheSettings
+
+	mutex   sync.Mutex
+	clock   Clock
+	entries map[string]*list.Element
+	recency *list.List
+
+	// generation is incremented on every invalidation, so that reads started
+	// before it do not cache what they read.
+	generation uint64
+}
+
+// NewCachedKeyStorage returns a KeyStorage that serves Get from a bounded LRU
+// cache in front of the given storage, which stays authoritative: every write
+// goes through to it and invalidates the cached entries it affects.
+// A nil settings value uses DefaultKeyCacheSettings.
+//
+// Writes made by other processes to the underlying storage, such as a
+// revocation, are seen once the cached entries expire, after at most TTL.
+//
+// The storage implements KeyLister, KeyWatcher and KeyPurger if, and only if,
+// the given storage does.
+//
+//	storage := krot.NewCachedKeyStorage(redisStorage, nil)
+func NewCachedKeyStorage(storage KeyStorage, settings *KeyCacheSettings) KeyStorage {
+	if settings == nil {
+		settings = DefaultKeyCacheSettings()
+	}
+
+	cache := &cachedStorage{
+		storage:  storage,
+		settings: *settings,
+		clock:    NewClock(),
+		entries:  make(map[string]*list.Element),
+		recency:  list.New(),
+	}
+
+	if cache.settings.Size <= 0 {
+		cache.settings.Size = DefaultKeyCacheSize
+	}
+
+	if cache.settings.TTL <= 0 {
+		cache.settings.TTL = DefaultKeyCacheTTL
+	}
+
+	return exposeOptional(cache, storage)
+}
+
+func (s *cachedStorage) setClock(clock Clock) {
+	s.mutex.Lock()
+	s.clock = clock
+	s.mutex.Unlock()
+
+	if setter, ok := s.storage.(clockSetter); ok {
+		setter.setClock(clock)
+	}
+}
+
+func (s *cachedStorage) Get(ctx context.Context, id string) (*Key, error) {
+	s.mutex.Lock()
+	now := s.clock.Now()
+	if element, ok := s.entries[id]; ok {
+		entry := element.Value.(*cacheEntry)
+		if now.Before(entry.expires) {
+			s.recency.MoveToFront(element)
+			s.mutex.Unlock()
+
+			if entry.key == nil {
+				return nil, errors.Join(ErrKeyNotFound, fmt.Errorf("key %s not found", id))
+			}
+
+			return entry.key, nil
+		}
+
+		s.remove(element)
+	}
+	generation := s.generation
+	s.mutex.Unlock()
+
+	key, err := s.storage.Get(ctx, id)
+	switch {
+	case err == nil:
+		expires := now.Add(s.settings.TTL)
+		if key.Expires.Before(expires) {
+			expires = key.Expires
+		}
+
+		s.put(generation, &cacheEntry{id: id, key: key, expires: expires})
+
+	case errors.Is(err, ErrKeyNotFound) && s.settings.NegativeTTL > 0:
+		s.put(generation, &cacheEntry{id: id, expires: now.Add(s.settings.NegativeTTL)})
+	}
+
+	return key, err
+}
+
+func (s *cachedStorage) Add(ctx context.Context, keys ...*Key) error {
+	defer func() {
+		ids := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if key != nil {
+				ids = append(ids, key.ID)
+			}
+		}
+
+		s.invalidate(ids...)
+	}()
+
+	return s.storage.Add(ctx, keys...)
+}
+
+func (s *cachedStorage) Delete(ctx context.Context, ids ...string) error {
+	defer s.invalidate(ids...)
+
+	return s.storage.Delete(ctx, ids...)
+}
+
+func (s *cachedStorage) List(ctx context.Context, options *KeyListOptions) ([]*Key, error) {
+	lister, ok := s.storage.(KeyLister)
+	if !ok {
+		return nil, fmt.Errorf("%w: underlying storage must implement KeyLister", ErrUnsupportedStorage)
+	}
+
+	return lister.List(ctx, options)
+}
+
+func (s *cachedStorage) Watch(ctx context.Context) (<-chan struct{}, error) {
+	watcher, ok := s.storage.(KeyWatcher)
+	if !ok {
+		return nil, fmt.Errorf("%w: underlying storage must implement KeyWatcher", ErrUnsupportedStorage)
+	}
+
+	return watcher.Watch(ctx)
+}
+
+func (s *cachedStorage) ClearDeprecated(ctx context.Context) error {
+	defer s.purge()
+
+	return s.storage.ClearDeprecated(ctx)
+}
+
+func (s *cachedStorage) PurgeDeprecated(ctx context.Context) (int, error) {
+	purger, ok := s.storage.(KeyPurger)
+	if !ok {
+		return 0, fmt.Errorf("%w: underlying storage must implement KeyPurger", ErrUnsupportedStorage)
+	}
+
+	defer s.purge()
+
+	return purger.PurgeDeprecated(ctx)
+}
+
+func (s *cachedStorage) Erase(ctx context.Context) error {
+	defer s.purge()
+
+	return s.storage.Erase(ctx)
+}
+
+// put caches the entry, unless the cache was invalidated since the given
+// generation, evicting the least recently used entries beyond the size.
+func (s *cachedStorage) put(generation uint64, entry *cacheEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+
+	if element, ok := s.entries[entry.id]; ok {
+		s.remove(element)
+	}
+
+	s.entries[entry.id] = s.recency.PushFront(entry)
+
+	for s.recency.Len() > s.settings.Size {
+		s.remove(s.recency.Back())
+	}
+}
+
+// invalidate removes the entries of the given IDs.
+func (s *cachedStorage) invalidate(ids ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.generation++
+	for _, id := range ids {
+		if element, ok := s.entries[id]; ok {
+			s.remove(element)
+		}
+	}
+}
+
+// purge removes every entry.
+func (s *cachedStorage) purge() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.generation++
+	s.entries = make(map[string]*list.Element)
+	s.recency.Init()
+}
+
+// remove removes the entry of the given element.
+// The mutex must be locked.
+func (s *cachedStorage) remove(element *list.Element) {
+	s.recency.Remove(element)
+	delete(s.entries, element.Value.(*cacheEntry).id)
+}
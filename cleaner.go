@@ -81,10 +81,26 @@ type keyCleaner struct {
 	cancel context.CancelFunc
 
 	storage KeyStorage
+	clock   Clock
+
+	// onCleaned, if set, is called with the number of keys removed by each
+	// cleaning that removed any. It is never called for storages that do not
+	// implement KeyPurger, as they cannot tell.
+	onCleaned func(count int)
 }
 
 func NewKeyCleaner(storage KeyStorage) KeyCleaner {
-	return &keyCleaner{storage: storage}
+	return NewKeyCleanerWithClock(storage, NewClock())
+}
+
+// NewKeyCleanerWithClock returns a KeyCleaner that waits between cleanings
+// using the given clock.
+func NewKeyCleanerWithClock(storage KeyStorage, clock Clock) KeyCleaner {
+	return &keyCleaner{storage: storage, clock: clock}
+}
+
+func (c *keyCleaner) setClock(clock Clock) {
+	c.clock = clock
 }
 
 func (c *keyCleaner) State() KeyCleanerState {
@@ -118,7 +134,7 @@ func (c *keyCleaner) Start(ctx context.Context, interval time.Duration) error {
 
 	c.ctx, c.cancel = context.WithCancel(ctx)
 	c.status = KeyCleanerStatusStarted
-	go c.run(ctx, interval)
+	go c.run(c.ctx, interval)
 
 	c.onStartHooks.Run(c)
 	return nil
@@ -132,20 +148,33 @@ func (c *keyCleaner) Stop() {
 	}
 }
 
-func (c *keyCleaner) run(context context.Context, interval time.Duration) {
+func (c *keyCleaner) run(ctx context.Context, interval time.Duration) {
 	for {
+		c.state = KeyCleanerStateIdle
+
 		select {
-		case <-c.ctx.Done():
+		case <-ctx.Done():
 			return
 
-		default:
-			c.state = KeyCleanerStateIdle
-			time.Sleep(interval)
+		case <-c.clock.After(interval):
+		}
 
-			c.beforeCleaningHooks.Run(c)
-			c.state = KeyCleanerStateCleaning
-			c.storage.ClearDeprecated(context)
-			c.afterCleaningHooks.Run(c)
+		c.beforeCleaningHooks.Run(c)
+		c.state = KeyCleanerStateCleaning
+		count, err := purgeDeprecated(ctx, c.storage)
+		if err == nil && count > 0 && c.onCleaned != nil {
+			c.onCleaned(count)
 		}
+		c.afterCleaningHooks.Run(c)
 	}
 }
+
+// purgeDeprecated removes the expired keys from the storage and returns how
+// many were removed, or -1 if the storage does not implement KeyPurger.
+func purgeDeprecated(ctx context.Context, storage KeyStorage) (int, error) {
+	if purger, ok := storage.(KeyPurger); ok {
+		return purger.PurgeDeprecated(ctx)
+	}
+
+	return -1, storage.ClearDeprecated(ctx)
+}
@@ -0,0 +1,36 @@
+package krot
+
+import "time"
+
+// Clock abstracts the passage of time for the Rotator, the KeyCleaner and the
+// in-memory KeyStorage, so that rotation and expiration can be tested without
+// waiting for them. The krottest package provides a manually advanced Clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// clockSetter is implemented by the components of this package that can be
+// handed the Rotator's clock.
+type clockSetter interface {
+	setClock(clock Clock)
+}
+
+type systemClock struct{}
+
+// NewClock returns a Clock backed by the system time.
+func NewClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,469 @@
+package krot
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sync"
+)
+
+const (
+	// KeyCodecRaw is the name of the codec of string values, such as the
+	// secrets created by the default key generator, which are stored verbatim.
+	KeyCodecRaw = "raw"
+
+	// KeyCodecHex is the name of the codec of []byte values stored as hex.
+	KeyCodecHex = "hex"
+
+	// KeyCodecBase64 is the name of the codec of []byte values stored as
+	// standard base64.
+	KeyCodecBase64 = "base64"
+
+	// KeyCodecPEM is the name of the codec of bare private keys, stored as
+	// PKCS#8 PEM blocks, and bare public keys, stored as PKIX PEM blocks.
+	KeyCodecPEM = "pem"
+
+	// KeyCodecPKCS8 is the name of the codec of *KeyPair values, stored as the
+	// PKCS#8 PEM block of their private half along with their algorithm.
+	KeyCodecPKCS8 = "pkcs8"
+
+	// KeyCodecJWK is the name of the codec of bare public keys stored as JWK.
+	KeyCodecJWK = "jwk"
+)
+
+// KeyCodec encodes key values as text, so that they survive a round trip
+// through a KeyStorage that is not in memory. Encoded values are stored along
+// with the name of their codec, which is used to decode them back.
+type KeyCodec interface {
+	// Name returns the name the encoded values are tagged with, e.g. "pem".
+	Name() string
+
+	// Accepts reports whether the codec can encode the value.
+	Accepts(value any) bool
+
+	// Encode encodes the value as text.
+	Encode(value any) (string, error)
+
+	// Decode decodes a value from the text returned by Encode.
+	Decode(data string) (any, error)
+}
+
+var keyCodecs = struct {
+	mu     sync.RWMutex
+	byName map[string]KeyCodec
+	codecs []KeyCodec
+}{
+	byName: map[string]KeyCodec{},
+}
+
+func init() {
+	for _, codec := range []KeyCodec{
+		NewRawKeyCodec(),
+		NewHexKeyCodec(),
+		NewBase64KeyCodec(),
+		NewPEMKeyCodec(),
+		NewPKCS8KeyCodec(),
+		NewJWKKeyCodec(),
+	} {
+		if err := RegisterKeyCodec(codec); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterKeyCodec registers a codec, replacing the one with the same name.
+// Values are encoded by the last registered codec accepting them, so that the
+// built-in codecs can be overridden: []byte values are stored as base64 by
+// default, but as hex after
+//
+//	krot.RegisterKeyCodec(krot.NewHexKeyCodec())
+//
+// Values encoded by any registered codec can be decoded, whatever the codec
+// encoding new values. If the codec is nil or has no name, it returns an
+// ErrInvalidArgument.
+func RegisterKeyCodec(codec KeyCodec) error {
+	if codec == nil {
+		return fmt.Errorf("%w: codec cannot be nil", ErrInvalidArgument)
+	}
+
+	name := codec.Name()
+	if name == "" {
+		return fmt.Errorf("%w: codec name cannot be empty", ErrInvalidArgument)
+	}
+
+	keyCodecs.mu.Lock()
+	defer keyCodecs.mu.Unlock()
+
+	codecs := make([]KeyCodec, 0, len(keyCodecs.codecs)+1)
+	for _, registered := range keyCodecs.codecs {
+		if registered.Name() != name {
+			codecs = append(codecs, registered)
+		}
+	}
+
+	keyCodecs.codecs = append(codecs, codec)
+	keyCodecs.byName[name] = codec
+
+	return nil
+}
+
+// LookupKeyCodec returns the registered codec with the given name.
+func LookupKeyCodec(name string) (KeyCodec, bool) {
+	keyCodecs.mu.RLock()
+	defer keyCodecs.mu.RUnlock()
+
+	codec, ok := keyCodecs.byName[name]
+	return codec, ok
+}
+
+// KeyCodecFor returns the registered codec encoding the given value.
+func KeyCodecFor(value any) (KeyCodec, bool) {
+	keyCodecs.mu.RLock()
+	defer keyCodecs.mu.RUnlock()
+
+	for i := len(keyCodecs.codecs) - 1; i >= 0; i-- {
+		if keyCodecs.codecs[i].Accepts(value) {
+			return keyCodecs.codecs[i], true
+		}
+	}
+
+	return nil, false
+}
+
+type encodedKeyValue struct {
+	Codec string `json:"codec"`
+	Data  string `json:"data"`
+}
+
+// MarshalKeyValue returns the JSON encoding of a key value. Values accepted by
+// a registered codec are encoded as {"codec": name, "data": text}; any other
+// value is encoded as plain JSON, and may not be decoded to the same type.
+//
+// Storages keeping values apart from the rest of the key use it, while
+// Key.MarshalJSON uses it for the "value" field.
+func MarshalKeyValue(value any) ([]byte, error) {
+	if value == nil {
+		return []byte("null"), nil
+	}
+
+	codec, ok := KeyCodecFor(value)
+	if !ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+		}
+
+		return data, nil
+	}
+
+	data, err := codec.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s codec: %w", ErrUnsupportedKeyType, codec.Name(), err)
+	}
+
+	return json.Marshal(&encodedKeyValue{Codec: codec.Name(), Data: data})
+}
+
+// UnmarshalKeyValue decodes a key value from its JSON encoding, as returned by
+// MarshalKeyValue. Values tagged with an unknown codec return an
+// ErrUnsupportedKeyType.
+func UnmarshalKeyValue(data []byte) (any, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if data[0] == '{' {
+		encoded := &encodedKeyValue{}
+		if err := json.Unmarshal(data, encoded); err == nil && encoded.Codec != "" {
+			codec, ok := LookupKeyCodec(encoded.Codec)
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown codec %q", ErrUnsupportedKeyType, encoded.Codec)
+			}
+
+			value, err := codec.Decode(encoded.Data)
+			if err != nil {
+				return nil, fmt.Errorf("%s codec: %w", codec.Name(), err)
+			}
+
+			return value, nil
+		}
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+type rawKeyCodec struct{}
+
+// NewRawKeyCodec returns the KeyCodec of string values, named KeyCodecRaw.
+func NewRawKeyCodec() KeyCodec {
+	return rawKeyCodec{}
+}
+
+func (rawKeyCodec) Name() string {
+	return KeyCodecRaw
+}
+
+func (rawKeyCodec) Accepts(value any) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func (rawKeyCodec) Encode(value any) (string, error) {
+	return value.(string), nil
+}
+
+func (rawKeyCodec) Decode(data string) (any, error) {
+	return data, nil
+}
+
+type hexKeyCodec struct{}
+
+// NewHexKeyCodec returns the KeyCodec of []byte values stored as hex, named
+// KeyCodecHex. It is registered, but overridden by the base64 codec for
+// encoding.
+func NewHexKeyCodec() KeyCodec {
+	return hexKeyCodec{}
+}
+
+func (hexKeyCodec) Name() string {
+	return KeyCodecHex
+}
+
+func (hexKeyCodec) Accepts(value any) bool {
+	_, ok := value.([]byte)
+	return ok
+}
+
+func (hexKeyCodec) Encode(value any) (string, error) {
+	return hex.EncodeToString(value.([]byte)), nil
+}
+
+func (hexKeyCodec) Decode(data string) (any, error) {
+	return hex.DecodeString(data)
+}
+
+type base64KeyCodec struct{}
+
+// NewBase64KeyCodec returns the KeyCodec of []byte values stored as standard
+// base64, named KeyCodecBase64.
+func NewBase64KeyCodec() KeyCodec {
+	return base64KeyCodec{}
+}
+
+func (base64KeyCodec) Name() string {
+	return KeyCodecBase64
+}
+
+func (base64KeyCodec) Accepts(value any) bool {
+	_, ok := value.([]byte)
+	return ok
+}
+
+func (base64KeyCodec) Encode(value any) (string, error) {
+	return base64.StdEncoding.EncodeToString(value.([]byte)), nil
+}
+
+func (base64KeyCodec) Decode(data string) (any, error) {
+	return base64.StdEncoding.DecodeString(data)
+}
+
+const (
+	pemPrivateKeyType = "PRIVATE KEY"
+	pemPublicKeyType  = "PUBLIC KEY"
+	pemAlgorithm      = "Algorithm"
+)
+
+type pemKeyCodec struct{}
+
+// NewPEMKeyCodec returns the KeyCodec of bare *rsa.PrivateKey,
+// *ecdsa.PrivateKey and ed25519.PrivateKey values, stored as PKCS#8 PEM
+// blocks, and of their public halves, stored as PKIX PEM blocks. It is named
+// KeyCodecPEM, and is overridden by the JWK codec for encoding public keys.
+func NewPEMKeyCodec() KeyCodec {
+	return pemKeyCodec{}
+}
+
+func (pemKeyCodec) Name() string {
+	return KeyCodecPEM
+}
+
+func (pemKeyCodec) Accepts(value any) bool {
+	switch value.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return true
+
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (pemKeyCodec) Encode(value any) (string, error) {
+	switch value.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		data, err := x509.MarshalPKIXPublicKey(value)
+		if err != nil {
+			return "", err
+		}
+
+		return string(pem.EncodeToMemory(&pem.Block{Type: pemPublicKeyType, Bytes: data})), nil
+
+	default:
+		data, err := x509.MarshalPKCS8PrivateKey(value)
+		if err != nil {
+			return "", err
+		}
+
+		return string(pem.EncodeToMemory(&pem.Block{Type: pemPrivateKeyType, Bytes: data})), nil
+	}
+}
+
+func (pemKeyCodec) Decode(data string) (any, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case pemPrivateKeyType:
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+
+	case pemPublicKeyType:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+	}
+}
+
+type pkcs8KeyCodec struct{}
+
+// NewPKCS8KeyCodec returns the KeyCodec of *KeyPair values, named
+// KeyCodecPKCS8. Pairs are stored as the PKCS#8 PEM block of their private
+// half, carrying their algorithm as a header; their public half is derived
+// from the private one when decoded.
+func NewPKCS8KeyCodec() KeyCodec {
+	return pkcs8KeyCodec{}
+}
+
+func (pkcs8KeyCodec) Name() string {
+	return KeyCodecPKCS8
+}
+
+func (pkcs8KeyCodec) Accepts(value any) bool {
+	pair, ok := value.(*KeyPair)
+	return ok && pair != nil
+}
+
+func (pkcs8KeyCodec) Encode(value any) (string, error) {
+	pair := value.(*KeyPair)
+
+	data, err := x509.MarshalPKCS8PrivateKey(pair.Private)
+	if err != nil {
+		return "", err
+	}
+
+	block := &pem.Block{Type: pemPrivateKeyType, Bytes: data}
+	if pair.Algorithm != "" {
+		block.Headers = map[string]string{pemAlgorithm: pair.Algorithm}
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+func (pkcs8KeyCodec) Decode(data string) (any, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type != pemPrivateKeyType {
+		return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%T cannot sign", privateKey)
+	}
+
+	return &KeyPair{
+		Private:   signer,
+		Public:    signer.Public(),
+		Algorithm: block.Headers[pemAlgorithm],
+	}, nil
+}
+
+func decodePEM(data string) (*pem.Block, error) {
+	block, rest := pem.Decode([]byte(data))
+	if block == nil || len(bytes.TrimSpace(rest)) > 0 {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+
+	return block, nil
+}
+
+type jwkKeyCodec struct{}
+
+// NewJWKKeyCodec returns the KeyCodec of bare *rsa.PublicKey, *ecdsa.PublicKey
+// and ed25519.PublicKey values stored as JWK, named KeyCodecJWK.
+func NewJWKKeyCodec() KeyCodec {
+	return jwkKeyCodec{}
+}
+
+func (jwkKeyCodec) Name() string {
+	return KeyCodecJWK
+}
+
+func (jwkKeyCodec) Accepts(value any) bool {
+	switch value.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (jwkKeyCodec) Encode(value any) (string, error) {
+	jwk, err := newPublicJWK(value)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (jwkKeyCodec) Decode(data string) (any, error) {
+	jwk := &JWK{}
+	if err := json.Unmarshal([]byte(data), jwk); err != nil {
+		return nil, err
+	}
+
+	return jwk.PublicKey()
+}
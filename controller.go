@@ -40,7 +40,11 @@ func (c *RotationController) Disposed() bool {
 }
 
 func (c *RotationController) TurnOn() {
-	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.TurnOnContext(context.Background())
+}
+
+func (c *RotationController) TurnOnContext(ctx context.Context) {
+	c.ctx, c.cancel = context.WithCancel(ctx)
 }
 
 func (c *RotationController) TurnOff() {
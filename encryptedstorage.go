@@ -0,0 +1,208 @@
+package krot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// dataKeySize is the size of the AES-256 keys encrypting each key value.
+const dataKeySize = 32
+
+// encryptedValue is the envelope replacing the value of an encrypted key: the
+// value is encrypted under a data key of its own, which is in turn wrapped by
+// the KeyWrapper.
+type encryptedValue struct {
+	DataKey []byte `json:"data_key"`
+	Value   []byte `json:"value"`
+}
+
+type encryptedStorage struct {
+	storage KeyStorage
+	wrapper KeyWrapper
+}
+
+// NewEncryptedKeyStorage returns a KeyStorage that encrypts key values before
+// handing them to the given storage, and decrypts them when reading them back,
+// so that whoever can read the storage cannot read the keys.
+//
+// Each value is encoded as JSON and encrypted with AES-GCM under a random data
+// key, bound to the key ID. The data key is encrypted by the KeyWrapper and
+// stored along with the value, as a string, so the underlying storage only has
+// to persist strings. IDs, expirations and states are stored in the clear.
+//
+// The storage implements KeyLister, KeyWatcher and KeyPurger if, and only if,
+// the given storage does.
+//
+//	wrapper, err := krot.NewEnvKeyWrapper("KROT_KEK")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	rotator.SetStorage(krot.NewEncryptedKeyStorage(redisStorage, wrapper))
+func NewEncryptedKeyStorage(storage KeyStorage, wrapper KeyWrapper) KeyStorage {
+	return exposeOptional(&encryptedStorage{storage: storage, wrapper: wrapper}, storage)
+}
+
+func (s *encryptedStorage) setClock(clock Clock) {
+	if setter, ok := s.storage.(clockSetter); ok {
+		setter.setClock(clock)
+	}
+}
+
+func (s *encryptedStorage) Get(ctx context.Context, id string) (*Key, error) {
+	key, err := s.storage.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decrypt(ctx, key)
+}
+
+func (s *encryptedStorage) Add(ctx context.Context, keys ...*Key) error {
+	encrypted := make([]*Key, 0, len(keys))
+	for _, key := range keys {
+		if key == nil || key.ID == "" || key.Value == "" {
+			continue
+		}
+
+		key, err := s.encrypt(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		encrypted = append(encrypted, key)
+	}
+
+	return s.storage.Add(ctx, encrypted...)
+}
+
+func (s *encryptedStorage) Delete(ctx context.Context, ids ...string) error {
+	return s.storage.Delete(ctx, ids...)
+}
+
+func (s *encryptedStorage) List(ctx context.Context, options *KeyListOptions) ([]*Key, error) {
+	lister, ok := s.storage.(KeyLister)
+	if !ok {
+		return nil, fmt.Errorf("%w: underlying storage must implement KeyLister", ErrUnsupportedStorage)
+	}
+
+	keys, err := lister.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make([]*Key, len(keys))
+	for i, key := range keys {
+		if decrypted[i], err = s.decrypt(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return decrypted, nil
+}
+
+func (s *encryptedStorage) Watch(ctx context.Context) (<-chan struct{}, error) {
+	watcher, ok := s.storage.(KeyWatcher)
+	if !ok {
+		return nil, fmt.Errorf("%w: underlying storage must implement KeyWatcher", ErrUnsupportedStorage)
+	}
+
+	return watcher.Watch(ctx)
+}
+
+func (s *encryptedStorage) ClearDeprecated(ctx context.Context) error {
+	return s.storage.ClearDeprecated(ctx)
+}
+
+func (s *encryptedStorage) PurgeDeprecated(ctx context.Context) (int, error) {
+	purger, ok := s.storage.(KeyPurger)
+	if !ok {
+		return 0, fmt.Errorf("%w: underlying storage must implement KeyPurger", ErrUnsupportedStorage)
+	}
+
+	return purger.PurgeDeprecated(ctx)
+}
+
+func (s *encryptedStorage) Erase(ctx context.Context) error {
+	return s.storage.Erase(ctx)
+}
+
+// encrypt returns a copy of the key whose value is encrypted.
+func (s *encryptedStorage) encrypt(ctx context.Context, key *Key) (*Key, error) {
+	plaintext, err := MarshalKeyValue(key.Value)
+	if err != nil {
+		return nil, fmt.Errorf("key %s value: %w", key.ID, err)
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := &encryptedValue{}
+	if envelope.Value, err = sealAESGCM(aead, plaintext, []byte(key.ID)); err != nil {
+		return nil, err
+	}
+
+	if envelope.DataKey, err = s.wrapper.WrapKey(ctx, dataKey); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := *key
+	encrypted.Value = base64.StdEncoding.EncodeToString(data)
+
+	return &encrypted, nil
+}
+
+// decrypt returns a copy of the key whose value is decrypted.
+func (s *encryptedStorage) decrypt(ctx context.Context, key *Key) (*Key, error) {
+	value, ok := key.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: key %s is not encrypted", ErrKeyDecryptionFailed, key.ID)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key %s is not encrypted", ErrKeyDecryptionFailed, key.ID)
+	}
+
+	envelope := &encryptedValue{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, fmt.Errorf("%w: key %s is not encrypted", ErrKeyDecryptionFailed, key.ID)
+	}
+
+	dataKey, err := s.wrapper.UnwrapKey(ctx, envelope.DataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key %s: %w", ErrKeyDecryptionFailed, key.ID, err)
+	}
+
+	plaintext, err := openAESGCM(aead, envelope.Value, []byte(key.ID))
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", key.ID, err)
+	}
+
+	decrypted := *key
+	if decrypted.Value, err = UnmarshalKeyValue(plaintext); err != nil {
+		return nil, fmt.Errorf("%w: key %s value: %w", ErrKeyDecryptionFailed, key.ID, err)
+	}
+
+	return &decrypted, nil
+}
@@ -33,6 +33,12 @@ const (
 
 	// ErrCodeInvalidKeyProvidingMode is used when the key providing mode is invalid.
 	ErrCodeInvalidKeyProvidingMode
+
+	// ErrCodeInvalidRotationSchedule is used when the rotation schedule is invalid.
+	ErrCodeInvalidRotationSchedule
+
+	// ErrCodeInvalidMaxKeyUses is used when the maximum number of key uses is invalid.
+	ErrCodeInvalidMaxKeyUses
 )
 
 const (
@@ -50,6 +56,30 @@ const (
 
 	// ErrCodeKeyNotFound is used when a key is not found in the storage.
 	ErrCodeKeyNotFound
+
+	// ErrCodeUnsupportedKeyType is used when a key value is of a type that cannot be handled.
+	ErrCodeUnsupportedKeyType
+
+	// ErrCodeKeyRevoked is used when a revoked key is requested.
+	ErrCodeKeyRevoked
+
+	// ErrCodeUnsupportedStorage is used when the storage does not support an operation.
+	ErrCodeUnsupportedStorage
+
+	// ErrCodeStateNotFound is used when no rotator state was saved.
+	ErrCodeStateNotFound
+
+	// ErrCodeKeyDecryptionFailed is used when an encrypted key cannot be decrypted.
+	ErrCodeKeyDecryptionFailed
+
+	// ErrCodeKeyTypeMismatch is used when a key value is not of the expected type.
+	ErrCodeKeyTypeMismatch
+
+	// ErrCodeKeyExpired is used when an expired key is used.
+	ErrCodeKeyExpired
+
+	// ErrCodeKeyExhausted is used when a key has been used MaxKeyUses times.
+	ErrCodeKeyExhausted
 )
 
 type KrotError interface {
@@ -67,6 +97,31 @@ var (
 	// ErrKeyNotFound is returned when a key is not found in the storage.
 	ErrKeyNotFound = newError(ErrCodeKeyNotFound, "key not found")
 
+	// ErrUnsupportedKeyType is returned when a key value is of a type that cannot be handled.
+	ErrUnsupportedKeyType = newError(ErrCodeUnsupportedKeyType, "unsupported key type")
+
+	// ErrKeyRevoked is returned when a revoked key is requested.
+	ErrKeyRevoked = newError(ErrCodeKeyRevoked, "key revoked")
+
+	// ErrUnsupportedStorage is returned when the storage does not support an operation.
+	ErrUnsupportedStorage = newError(ErrCodeUnsupportedStorage, "unsupported storage")
+
+	// ErrStateNotFound is returned when no rotator state was saved.
+	ErrStateNotFound = newError(ErrCodeStateNotFound, "rotator state not found")
+
+	// ErrKeyDecryptionFailed is returned when an encrypted key cannot be decrypted.
+	ErrKeyDecryptionFailed = newError(ErrCodeKeyDecryptionFailed, "key decryption failed")
+
+	// ErrKeyTypeMismatch is returned when a key value is not of the expected type.
+	ErrKeyTypeMismatch = newError(ErrCodeKeyTypeMismatch, "key type mismatch")
+
+	// ErrKeyExpired is returned when an expired key is used.
+	ErrKeyExpired = newError(ErrCodeKeyExpired, "key expired")
+
+	// ErrKeyExhausted is returned when the only key left to sign has been used MaxKeyUses times
+	// and its replacement is not active yet.
+	ErrKeyExhausted = newError(ErrCodeKeyExhausted, "key exhausted")
+
 	// ErrInvalidSettings is returned when the settings are invalid.
 	ErrInvalidSettings = newError(ErrCodeInvalidSettings, "invalid settings")
 
@@ -90,6 +145,12 @@ var (
 
 	// ErrInvalidKeyProvidingMode is returned when the key providing mode is invalid.
 	ErrInvalidKeyProvidingMode = newError(ErrCodeInvalidKeyProvidingMode, "invalid key providing mode")
+
+	// ErrInvalidRotationSchedule is returned when the rotation schedule is invalid.
+	ErrInvalidRotationSchedule = newError(ErrCodeInvalidRotationSchedule, "invalid rotation schedule")
+
+	// ErrInvalidMaxKeyUses is returned when the maximum number of key uses is invalid.
+	ErrInvalidMaxKeyUses = newError(ErrCodeInvalidMaxKeyUses, "invalid max key uses")
 )
 
 type krotErrorJSON struct {
@@ -0,0 +1,96 @@
+package krot
+
+import (
+	"context"
+	"sync"
+)
+
+// RotatorEventBufferSize is the number of events a subscription buffers before
+// further events are dropped.
+const RotatorEventBufferSize = 64
+
+// RotatorEvent is an event emitted by a Rotator to its subscribers: one of
+// KeysRotated, KeysRevoked, KeysCleaned or RotationFailed.
+type RotatorEvent interface {
+	rotatorEvent()
+}
+
+// KeysRotated is emitted whenever keys join or leave the signing pool: after
+// every rotation, after a follower loads a new key set, and when a key is
+// retired after MaxKeyUses uses.
+type KeysRotated struct {
+	// Added holds the IDs of the keys added to the storage by the rotation,
+	// including the pending ones, or of the keys first loaded by a follower.
+	Added []string
+
+	// Retired holds the IDs of the keys that left the signing pool. They are
+	// valid for verification until they expire.
+	Retired []string
+}
+
+// KeysRevoked is emitted after keys are revoked.
+type KeysRevoked struct {
+	// IDs holds the IDs of the revoked keys.
+	IDs []string
+}
+
+// KeysCleaned is emitted after the key cleaner removes expired keys from the
+// storage. It is only emitted for storages that implement KeyPurger.
+type KeysCleaned struct {
+	// Count is the number of removed keys.
+	Count int
+}
+
+// RotationFailed is emitted whenever a rotation, including its hooks, returns
+// an error. A running Rotator retries failed scheduled rotations.
+type RotationFailed struct {
+	Err error
+}
+
+func (KeysRotated) rotatorEvent()    {}
+func (KeysRevoked) rotatorEvent()    {}
+func (KeysCleaned) rotatorEvent()    {}
+func (RotationFailed) rotatorEvent() {}
+
+// subscribers fans the events of a Rotator out to its subscriptions.
+type subscribers struct {
+	mutex    sync.Mutex
+	channels map[chan RotatorEvent]struct{}
+}
+
+// add returns a new subscription, which is closed once the context is done.
+func (s *subscribers) add(ctx context.Context) <-chan RotatorEvent {
+	events := make(chan RotatorEvent, RotatorEventBufferSize)
+
+	s.mutex.Lock()
+	if s.channels == nil {
+		s.channels = make(map[chan RotatorEvent]struct{})
+	}
+	s.channels[events] = struct{}{}
+	s.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mutex.Lock()
+		delete(s.channels, events)
+		close(events)
+		s.mutex.Unlock()
+	}()
+
+	return events
+}
+
+// emit sends the event to the subscriptions without blocking: subscribers
+// that fall RotatorEventBufferSize events behind miss the following ones.
+func (s *subscribers) emit(event RotatorEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for events := range s.channels {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
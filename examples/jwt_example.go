@@ -6,31 +6,28 @@ import (
 	"time"
 
 	"github.com/zhaori96/krot"
-
-	"github.com/dgrijalva/jwt-go"
+	"github.com/zhaori96/krot/jwt"
 )
 
-// JWTKeySigningHook is a custom RotatorHook that signs JWTs using the current key.
-var JWTKeySigningHook krot.RotatorHook = func(rotator *krot.Rotator) {
-	// Retrieve the current key from the Rotator
-	key, err := rotator.GetKey()
-	if err != nil {
-		fmt.Printf("Error getting key for signing JWT: %v\n", err)
-		return
+// JWTKeySigningHook returns a custom RotatorHook that signs JWTs using the current key.
+func JWTKeySigningHook(signer *jwt.Signer, verifier *jwt.Verifier) krot.RotatorHook {
+	return func(*krot.Rotator) {
+		signJWT(signer, verifier)
 	}
+}
 
+func signJWT(signer *jwt.Signer, verifier *jwt.Verifier) {
 	// Example claims for the JWT
-	claims := jwt.MapClaims{
-		"exp": key.Expires.Unix(),
-		"iat": time.Now().Unix(),
-		// Add your custom claims here
+	claims := &jwt.RegisteredClaims{
+		Subject:   "user",
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		// Embed RegisteredClaims in a struct of your own to add custom claims
 	}
 
-	// Create a new JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign the token with the current key's value
-	tokenString, err := token.SignedString([]byte(key.Value.(string)))
+	// Sign the token with a key of the Rotator; its ID is set as the "kid"
+	// header and the algorithm (EdDSA) is chosen from the key type
+	tokenString, err := signer.Sign(claims)
 	if err != nil {
 		fmt.Printf("Error signing JWT: %v\n", err)
 		return
@@ -38,17 +35,34 @@ var JWTKeySigningHook krot.RotatorHook = func(rotator *krot.Rotator) {
 
 	// Use the signed JWT as needed (e.g., include it in API responses)
 	fmt.Printf("Signed JWT: %s\n", tokenString)
+
+	// Verify the token with the key named by its "kid" header, which keeps
+	// working after later rotations until the key expires or is revoked
+	verified, err := verifier.Verify(tokenString)
+	if err != nil {
+		fmt.Printf("Error verifying JWT: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Verified JWT for %v\n", verified["sub"])
 }
 
 func main() {
-	// Initialize the Rotator
-	rotator := krot.New()
+	// Initialize the Rotator, whose keys are Ed25519 key pairs
+	rotator, err := krot.NewTyped(krot.Typed[*krot.KeyPair](krot.NewEd25519KeyGenerator()))
+	if err != nil {
+		fmt.Printf("Error creating rotator: %v\n", err)
+		return
+	}
+
+	signer := jwt.NewSigner(rotator.Rotator)
+	verifier := jwt.NewVerifier(rotator.Rotator)
 
 	// Add the custom JWT signing hook to the AfterRotation hooks
-	rotator.AfterRotation(JWTKeySigningHook)
+	rotator.AfterRotation(JWTKeySigningHook(signer, verifier))
 
 	// Start the Rotator
-	err := rotator.Start()
+	err = rotator.Start()
 	if err != nil {
 		fmt.Printf("Error starting rotator: %v\n", err)
 		return
@@ -0,0 +1,180 @@
+package krot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileStorage struct {
+	path  string
+	mutex sync.RWMutex
+	clock Clock
+}
+
+// NewFileKeyStorage returns a KeyStorage that persists keys as JSON in the file
+// at the given path, so that they survive restarts without an external
+// database. The file is created with 0600 permissions on the first write.
+//
+// Every write replaces the file atomically and syncs it to disk, while holding
+// an advisory lock on a sibling file with the ".lock" suffix, so that several
+// processes of the same host can share the storage. Readers never wait for
+// writers. Locking is supported on unix and Windows; on other platforms, every
+// write fails with errors.ErrUnsupported.
+//
+// Key values are encoded with MarshalKeyValue: values of a type with a
+// registered KeyCodec, such as []byte secrets and *KeyPair, are read back with
+// their type, while other values are stored as plain JSON.
+func NewFileKeyStorage(path string) KeyStorage {
+	return NewFileKeyStorageWithClock(path, NewClock())
+}
+
+// NewFileKeyStorageWithClock returns a file KeyStorage that tells expired keys
+// apart using the given clock.
+func NewFileKeyStorageWithClock(path string, clock Clock) KeyStorage {
+	return &fileStorage{path: path, clock: clock}
+}
+
+func (s *fileStorage) setClock(clock Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clock = clock
+}
+
+func (s *fileStorage) Get(ctx context.Context, id string) (*Key, error) {
+	keys, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := keys[id]
+	if !ok {
+		return nil, errors.Join(ErrKeyNotFound, fmt.Errorf("key %s not found", id))
+	}
+
+	return key, nil
+}
+
+func (s *fileStorage) Add(ctx context.Context, keys ...*Key) error {
+	return s.update(ctx, func(storage map[string]*Key) {
+		for _, key := range keys {
+			if key == nil || key.ID == "" || key.Value == "" {
+				continue
+			}
+
+			storage[key.ID] = key
+		}
+	})
+}
+
+func (s *fileStorage) Delete(ctx context.Context, ids ...string) error {
+	return s.update(ctx, func(storage map[string]*Key) {
+		for _, id := range ids {
+			delete(storage, id)
+		}
+	})
+}
+
+func (s *fileStorage) List(ctx context.Context, options *KeyListOptions) ([]*Key, error) {
+	storage, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(storage))
+	for _, key := range storage {
+		keys = append(keys, key)
+	}
+
+	return options.apply(keys, s.now()), nil
+}
+
+func (s *fileStorage) ClearDeprecated(ctx context.Context) error {
+	_, err := s.PurgeDeprecated(ctx)
+	return err
+}
+
+func (s *fileStorage) PurgeDeprecated(ctx context.Context) (int, error) {
+	count := 0
+	now := s.now()
+	err := s.update(ctx, func(storage map[string]*Key) {
+		for id, key := range storage {
+			if key == nil || key.ExpiredAt(now) {
+				delete(storage, id)
+				count++
+			}
+		}
+	})
+
+	return count, err
+}
+
+func (s *fileStorage) Erase(ctx context.Context) error {
+	return s.update(ctx, func(storage map[string]*Key) {
+		clear(storage)
+	})
+}
+
+func (s *fileStorage) now() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.clock.Now()
+}
+
+// read loads the keys from the file. A missing file holds no keys.
+func (s *fileStorage) read(ctx context.Context) (map[string]*Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]*Key), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*Key)
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("key storage %s is corrupted: %w", s.path, err)
+	}
+
+	return keys, nil
+}
+
+// update locks the storage, applies the given function to its keys and writes
+// them back.
+func (s *fileStorage) update(ctx context.Context, apply func(storage map[string]*Key)) error {
+	lock, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := lockFile(ctx, lock); err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+
+	keys, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	apply(keys)
+
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data, 0o600)
+}
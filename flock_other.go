@@ -0,0 +1,19 @@
+//go:build !unix && !windows
+
+package krot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+var errFileLocked = errors.New("file is locked")
+
+func tryLockFile(*os.File) error {
+	return fmt.Errorf("%w: file locking is not supported on this platform", errors.ErrUnsupported)
+}
+
+func unlockFile(*os.File) error {
+	return nil
+}
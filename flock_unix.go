@@ -0,0 +1,24 @@
+//go:build unix
+
+package krot
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var errFileLocked = errors.New("file is locked")
+
+func tryLockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errFileLocked
+	}
+
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,63 @@
+//go:build windows
+
+package krot
+
+import (
+	"errors"
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var errFileLocked = errors.New("file is locked")
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+func tryLockFile(file *os.File) error {
+	overlapped := new(syscall.Overlapped)
+	ok, _, err := procLockFileEx.Call(
+		file.Fd(),
+		lockfileExclusiveLock|lockfileFailImmediately,
+		0,
+		math.MaxUint32,
+		math.MaxUint32,
+		uintptr(unsafe.Pointer(overlapped)),
+	)
+	if ok != 0 {
+		return nil
+	}
+
+	if errors.Is(err, errorLockViolation) {
+		return errFileLocked
+	}
+
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	overlapped := new(syscall.Overlapped)
+	ok, _, err := procUnlockFileEx.Call(
+		file.Fd(),
+		0,
+		math.MaxUint32,
+		math.MaxUint32,
+		uintptr(unsafe.Pointer(overlapped)),
+	)
+	if ok != 0 {
+		return nil
+	}
+
+	return err
+}
@@ -1,8 +1,14 @@
 package krot
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"encoding/hex"
+	"fmt"
 )
 
 type KeySize int
@@ -16,6 +22,34 @@ const (
 	KeySize512 KeySize = 64
 )
 
+// RSAKeySize is the modulus size, in bits, of the keys created by an RSA key generator.
+type RSAKeySize int
+
+const (
+	RSAKeySize2048 RSAKeySize = 2048
+	RSAKeySize3072 RSAKeySize = 3072
+	RSAKeySize4096 RSAKeySize = 4096
+)
+
+// ECDSACurve is the elliptic curve used by an ECDSA key generator.
+type ECDSACurve int
+
+const (
+	ECDSACurveP256 ECDSACurve = iota
+	ECDSACurveP384
+	ECDSACurveP521
+)
+
+// Signing algorithms, as named by RFC 7518 and RFC 8037, of the key pairs
+// created by the asymmetric key generators.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmES384 = "ES384"
+	AlgorithmES512 = "ES512"
+	AlgorithmEdDSA = "EdDSA"
+)
+
 // KeyGenerator defines the interface for generating keys. It provides a method
 // for generating a key.
 type KeyGenerator interface {
@@ -28,6 +62,26 @@ type KeyGenerator interface {
 	Generate() (any, error)
 }
 
+// KeyPair is the value generated by the asymmetric key generators. The private
+// half is meant for signing and must be kept secret, while the public half can
+// be handed out to verifiers.
+//
+//	pair := key.Value.(*krot.KeyPair)
+//	signature, err := pair.Private.Sign(rand.Reader, digest, crypto.SHA256)
+type KeyPair struct {
+	// Private is the private half of the pair: *rsa.PrivateKey,
+	// *ecdsa.PrivateKey or ed25519.PrivateKey.
+	Private crypto.Signer
+
+	// Public is the public half of the pair: *rsa.PublicKey,
+	// *ecdsa.PublicKey or ed25519.PublicKey.
+	Public crypto.PublicKey
+
+	// Algorithm is the signing algorithm the pair is meant to be used with,
+	// e.g. AlgorithmRS256.
+	Algorithm string
+}
+
 type keyGenerator struct {
 	keySize int
 }
@@ -47,3 +101,94 @@ func (g *keyGenerator) Generate() (any, error) {
 
 	return hex.EncodeToString(key), nil
 }
+
+type rsaKeyGenerator struct {
+	keySize RSAKeySize
+}
+
+// NewRSAKeyGenerator returns a KeyGenerator that creates RSA key pairs of the
+// given size. The generated values are *KeyPair meant for RS256.
+func NewRSAKeyGenerator(size RSAKeySize) KeyGenerator {
+	return &rsaKeyGenerator{keySize: size}
+}
+
+func (g *rsaKeyGenerator) Generate() (any, error) {
+	switch g.keySize {
+	case RSAKeySize2048, RSAKeySize3072, RSAKeySize4096:
+	default:
+		return nil, fmt.Errorf("%w: unsupported RSA key size %d", ErrInvalidArgument, g.keySize)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, int(g.keySize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		Private:   privateKey,
+		Public:    &privateKey.PublicKey,
+		Algorithm: AlgorithmRS256,
+	}, nil
+}
+
+type ecdsaKeyGenerator struct {
+	curve ECDSACurve
+}
+
+// NewECDSAKeyGenerator returns a KeyGenerator that creates ECDSA key pairs on
+// the given curve. The generated values are *KeyPair meant for ES256, ES384 or
+// ES512, depending on the curve.
+func NewECDSAKeyGenerator(curve ECDSACurve) KeyGenerator {
+	return &ecdsaKeyGenerator{curve: curve}
+}
+
+func (g *ecdsaKeyGenerator) Generate() (any, error) {
+	var curve elliptic.Curve
+	var algorithm string
+
+	switch g.curve {
+	case ECDSACurveP256:
+		curve, algorithm = elliptic.P256(), AlgorithmES256
+
+	case ECDSACurveP384:
+		curve, algorithm = elliptic.P384(), AlgorithmES384
+
+	case ECDSACurveP521:
+		curve, algorithm = elliptic.P521(), AlgorithmES512
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported ECDSA curve %d", ErrInvalidArgument, g.curve)
+	}
+
+	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		Private:   privateKey,
+		Public:    &privateKey.PublicKey,
+		Algorithm: algorithm,
+	}, nil
+}
+
+type ed25519KeyGenerator struct{}
+
+// NewEd25519KeyGenerator returns a KeyGenerator that creates Ed25519 key pairs.
+// The generated values are *KeyPair meant for EdDSA.
+func NewEd25519KeyGenerator() KeyGenerator {
+	return &ed25519KeyGenerator{}
+}
+
+func (g *ed25519KeyGenerator) Generate() (any, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		Private:   privateKey,
+		Public:    publicKey,
+		Algorithm: AlgorithmEdDSA,
+	}, nil
+}
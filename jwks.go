@@ -0,0 +1,295 @@
+package krot
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// JWKUseSignature is the "use" parameter of keys meant for signature verification.
+const JWKUseSignature = "sig"
+
+// DefaultJWKSMaxAge is the default longest duration clients of a JWKS handler
+// may cache its key set.
+const DefaultJWKSMaxAge = 5 * time.Minute
+
+// JWK is the JSON Web Key (RFC 7517) representation of the public half of a key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+
+	// Curve, X and Y describe elliptic curve (EC) and Edwards curve (OKP) keys.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+
+	// N and E describe RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set (RFC 7517, section 5).
+type JWKSet struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// NewJWK returns the JWK of the public half of the given key, using the key ID
+// as "kid". The key value must be a *KeyPair or a bare *rsa.PublicKey,
+// *ecdsa.PublicKey or ed25519.PublicKey; any other value, such as a symmetric
+// secret, returns an ErrUnsupportedKeyType since it cannot be published.
+func NewJWK(key *Key) (*JWK, error) {
+	if key == nil {
+		return nil, fmt.Errorf("%w: key cannot be nil", ErrInvalidArgument)
+	}
+
+	var publicKey crypto.PublicKey
+	var algorithm string
+
+	switch value := key.Value.(type) {
+	case *KeyPair:
+		publicKey, algorithm = value.Public, value.Algorithm
+
+	default:
+		publicKey = value
+	}
+
+	jwk, err := newPublicJWK(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	jwk.KeyID = key.ID
+	jwk.Algorithm = algorithm
+	jwk.Use = JWKUseSignature
+
+	return jwk, nil
+}
+
+func newPublicJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			KeyType: "RSA",
+			N:       encodeJWKInt(publicKey.N.Bytes()),
+			E:       encodeJWKInt(big.NewInt(int64(publicKey.E)).Bytes()),
+		}, nil
+
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		return &JWK{
+			KeyType: "EC",
+			Curve:   publicKey.Curve.Params().Name,
+			X:       encodeJWKInt(publicKey.X.FillBytes(make([]byte, size))),
+			Y:       encodeJWKInt(publicKey.Y.FillBytes(make([]byte, size))),
+		}, nil
+
+	case ed25519.PublicKey:
+		return &JWK{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			X:       encodeJWKInt(publicKey),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %T has no public key", ErrUnsupportedKeyType, publicKey)
+	}
+}
+
+// PublicKey returns the public key described by the JWK: an *rsa.PublicKey,
+// *ecdsa.PublicKey or ed25519.PublicKey. Other key types return an
+// ErrUnsupportedKeyType.
+func (j *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := decodeJWKInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeJWKInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > math.MaxInt32 {
+			return nil, fmt.Errorf("%w: RSA exponent is too large", ErrInvalidArgument)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+
+		case "P-384":
+			curve = elliptic.P384()
+
+		case "P-521":
+			curve = elliptic.P521()
+
+		default:
+			return nil, fmt.Errorf("%w: unsupported curve %q", ErrUnsupportedKeyType, j.Curve)
+		}
+
+		x, err := decodeJWKInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeJWKInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("%w: point is not on curve %s", ErrInvalidArgument, j.Curve)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, fmt.Errorf("%w: unsupported curve %q", ErrUnsupportedKeyType, j.Curve)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: invalid Ed25519 public key size %d", ErrInvalidArgument, len(x))
+		}
+
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %q", ErrUnsupportedKeyType, j.KeyType)
+	}
+}
+
+func encodeJWKInt(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+type jwksHandler struct {
+	rotator *Rotator
+	maxAge  time.Duration
+}
+
+// NewJWKSHandler returns an http.Handler that publishes the public halves of
+// every non-expired, non-revoked key of the rotator as a JWK set, pending keys
+// included, typically mounted at
+// "/.well-known/jwks.json":
+//
+//	http.Handle("/.well-known/jwks.json", krot.NewJWKSHandler(rotator))
+//
+// Keys whose values have no public half, such as the secrets created by the
+// default key generator, are never published. Responses may be cached until
+// the next rotation, but no longer than DefaultJWKSMaxAge, as advertised by
+// the Cache-Control header. Clients keep trusting revoked keys until their
+// cached key set expires.
+func NewJWKSHandler(rotator *Rotator) http.Handler {
+	return NewJWKSHandlerWithMaxAge(rotator, DefaultJWKSMaxAge)
+}
+
+// NewJWKSHandlerWithMaxAge works like NewJWKSHandler, allowing responses to be
+// cached for at most the given duration instead of DefaultJWKSMaxAge. Shorter
+// durations let clients notice revocations sooner.
+// Values less than or equal to 0 use DefaultJWKSMaxAge.
+func NewJWKSHandlerWithMaxAge(rotator *Rotator, maxAge time.Duration) http.Handler {
+	if maxAge <= 0 {
+		maxAge = DefaultJWKSMaxAge
+	}
+
+	return &jwksHandler{rotator: rotator, maxAge: maxAge}
+}
+
+// JWKSHandler returns an http.Handler that publishes the public halves of
+// every non-expired, non-revoked key of the global rotator as a JWK set,
+// pending keys included. Responses may be cached until the next rotation, but
+// no longer than DefaultJWKSMaxAge. Clients keep trusting revoked keys until
+// their cached key set expires.
+func JWKSHandler() http.Handler { return NewJWKSHandler(rotator) }
+
+func (h *jwksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys, err := h.rotator.Keys(r.Context())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	set := &JWKSet{Keys: make([]*JWK, 0, len(keys))}
+	for _, key := range keys {
+		if !key.CanVerify() {
+			continue
+		}
+
+		jwk, err := NewJWK(key)
+		if errors.Is(err, ErrUnsupportedKeyType) {
+			continue
+		}
+
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	body, err := json.Marshal(set)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", h.cacheControl())
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodGet {
+		w.Write(body)
+	}
+}
+
+// cacheControl allows the key set to be cached until the next rotation, when
+// new keys are published, but no longer than the handler's max age, so that
+// revocations reach the clients.
+func (h *jwksHandler) cacheControl() string {
+	maxAge := min(h.rotator.nextRotation().Sub(h.rotator.now()), h.maxAge).Truncate(time.Second)
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+}
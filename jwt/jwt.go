@@ -0,0 +1,199 @@
+// Package jwt signs and verifies JSON Web Tokens with the keys of a
+// krot.Rotator, using github.com/golang-jwt/jwt/v5.
+//
+// Tokens carry the ID of their signing key as the "kid" header, so that they
+// can still be verified after rotations, as long as their key has neither
+// expired nor been revoked. The algorithm is chosen from the key type:
+//
+//   - *krot.KeyPair values use their own algorithm: RS256, ES256, ES384,
+//     ES512 or EdDSA.
+//   - string and []byte secrets, such as the ones of the default key
+//     generator, use HS256.
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jwtgo "github.com/golang-jwt/jwt/v5"
+	"github.com/zhaori96/krot"
+)
+
+// KeyIDHeader is the header holding the ID of the signing key.
+const KeyIDHeader = "kid"
+
+type (
+	// Claims are the claims of a token, validated by the Verifier.
+	Claims = jwtgo.Claims
+
+	// MapClaims are claims decoded as a map.
+	MapClaims = jwtgo.MapClaims
+
+	// RegisteredClaims are the registered claims of RFC 7519.
+	RegisteredClaims = jwtgo.RegisteredClaims
+
+	// NumericDate is the type of the time claims of RegisteredClaims.
+	NumericDate = jwtgo.NumericDate
+)
+
+// NewNumericDate returns the NumericDate of the given time, as the time claims
+// of RegisteredClaims expect.
+func NewNumericDate(t time.Time) *NumericDate {
+	return jwtgo.NewNumericDate(t)
+}
+
+// Signer signs tokens with the keys of a Rotator.
+type Signer struct {
+	rotator *krot.Rotator
+}
+
+// NewSigner returns a Signer using the keys of the given rotator.
+//
+//	signer := jwt.NewSigner(rotator)
+//	token, err := signer.Sign(jwt.MapClaims{"sub": "user"})
+func NewSigner(rotator *krot.Rotator) *Signer {
+	return &Signer{rotator: rotator}
+}
+
+// Sign signs the claims with a key picked by Rotator.GetKey, setting its ID as
+// the "kid" header. Keys whose values cannot sign tokens return an
+// krot.ErrUnsupportedKeyType.
+func (s *Signer) Sign(claims Claims) (string, error) {
+	return s.SignContext(context.Background(), claims)
+}
+
+// SignContext works like Sign, passing the given context to the storage.
+func (s *Signer) SignContext(ctx context.Context, claims Claims) (string, error) {
+	key, err := s.rotator.GetKeyContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	method, signingKey, _, err := keyMaterial(key)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwtgo.NewWithClaims(method, claims)
+	token.Header[KeyIDHeader] = key.ID
+
+	return token.SignedString(signingKey)
+}
+
+// Sign signs the claims with a key of the global rotator.
+func Sign(claims Claims) (string, error) {
+	return NewSigner(krot.GetRotator()).Sign(claims)
+}
+
+// Verifier verifies tokens signed with the keys of a Rotator.
+type Verifier struct {
+	rotator *krot.Rotator
+}
+
+// NewVerifier returns a Verifier using the keys of the given rotator.
+//
+//	verifier := jwt.NewVerifier(rotator)
+//	claims, err := verifier.Verify(token)
+func NewVerifier(rotator *krot.Rotator) *Verifier {
+	return &Verifier{rotator: rotator}
+}
+
+// Verify verifies the token and returns its claims.
+//
+// The key is resolved from the "kid" header through Rotator.GetKeyByID, so
+// tokens signed before a rotation remain valid. Tokens without a "kid" or
+// whose key is unknown return an krot.ErrKeyNotFound; tokens whose key was
+// revoked or has expired return an krot.ErrKeyRevoked or an krot.ErrKeyExpired;
+// tokens whose algorithm does not match their key return an
+// krot.ErrKeyTypeMismatch. Invalid signatures and claims return the errors of
+// golang-jwt, such as jwt.ErrTokenSignatureInvalid or jwt.ErrTokenExpired.
+//
+// Keys and claims are checked against the time of the rotator's Clock.
+func (v *Verifier) Verify(token string) (MapClaims, error) {
+	return v.VerifyContext(context.Background(), token)
+}
+
+// VerifyContext works like Verify, passing the given context to the storage.
+func (v *Verifier) VerifyContext(ctx context.Context, token string) (MapClaims, error) {
+	claims := MapClaims{}
+	if err := v.VerifyClaims(ctx, token, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// VerifyClaims works like VerifyContext, decoding the claims into the given
+// ones, e.g. a *RegisteredClaims or a custom type embedding it.
+func (v *Verifier) VerifyClaims(ctx context.Context, token string, claims Claims) error {
+	keyfunc := func(token *jwtgo.Token) (any, error) {
+		return v.verificationKey(ctx, token)
+	}
+
+	_, err := jwtgo.ParseWithClaims(token, claims, keyfunc, jwtgo.WithTimeFunc(v.rotator.Clock().Now))
+	return err
+}
+
+// Verify verifies the token with the keys of the global rotator and returns
+// its claims.
+func Verify(token string) (MapClaims, error) {
+	return NewVerifier(krot.GetRotator()).Verify(token)
+}
+
+// verificationKey returns the key verifying the token.
+func (v *Verifier) verificationKey(ctx context.Context, token *jwtgo.Token) (any, error) {
+	id, _ := token.Header[KeyIDHeader].(string)
+	if id == "" {
+		return nil, fmt.Errorf("%w: token has no %q header", krot.ErrKeyNotFound, KeyIDHeader)
+	}
+
+	key, err := v.rotator.GetKeyByIDContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.ExpiredAt(v.rotator.Clock().Now()) {
+		return nil, fmt.Errorf("%w: key %s expired at %s", krot.ErrKeyExpired, key.ID, key.Expires)
+	}
+
+	method, _, verificationKey, err := keyMaterial(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("%w: token is signed with %s, but key %s is meant for %s",
+			krot.ErrKeyTypeMismatch, token.Method.Alg(), key.ID, method.Alg())
+	}
+
+	return verificationKey, nil
+}
+
+// keyMaterial returns the signing method of a key, along with its signing and
+// verification keys.
+func keyMaterial(key *krot.Key) (jwtgo.SigningMethod, any, any, error) {
+	var algorithm string
+	var signingKey, verificationKey any
+
+	switch value := key.Value.(type) {
+	case *krot.KeyPair:
+		algorithm, signingKey, verificationKey = value.Algorithm, value.Private, value.Public
+
+	case string:
+		algorithm, signingKey, verificationKey = jwtgo.SigningMethodHS256.Alg(), []byte(value), []byte(value)
+
+	case []byte:
+		algorithm, signingKey, verificationKey = jwtgo.SigningMethodHS256.Alg(), value, value
+
+	default:
+		return nil, nil, nil, fmt.Errorf("%w: key %s holds a %T, which cannot sign tokens", krot.ErrUnsupportedKeyType, key.ID, key.Value)
+	}
+
+	method := jwtgo.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, nil, nil, fmt.Errorf("%w: key %s is meant for the unsupported algorithm %q", krot.ErrUnsupportedKeyType, key.ID, algorithm)
+	}
+
+	return method, signingKey, verificationKey, nil
+}
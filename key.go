@@ -1,14 +1,137 @@
 package krot
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// KeyState is the lifecycle state of a key.
+type KeyState uint
+
+const (
+	// KeyStateActive is the state of keys used for signing and verification.
+	// It is the zero value, so keys stored without a state are active.
+	KeyStateActive KeyState = iota
+
+	// KeyStatePending is the state of keys that have been published ahead of
+	// their activation. They can be used for verification but not for signing.
+	KeyStatePending
+
+	// KeyStateRetiring is the state of keys that have been replaced by a newer
+	// rotation. They can be used for verification until they expire.
+	KeyStateRetiring
+
+	// KeyStateRevoked is the state of keys that must no longer be trusted.
+	// They are rejected for both signing and verification.
+	KeyStateRevoked
+)
+
+var keyStateNames = map[KeyState]string{
+	KeyStateActive:   "active",
+	KeyStatePending:  "pending",
+	KeyStateRetiring: "retiring",
+	KeyStateRevoked:  "revoked",
+}
+
+// String returns the name of the state, e.g. "active".
+func (s KeyState) String() string {
+	if name, ok := keyStateNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("KeyState(%d)", uint(s))
+}
+
+// MarshalText encodes the state as its name.
+func (s KeyState) MarshalText() ([]byte, error) {
+	if _, ok := keyStateNames[s]; !ok {
+		return nil, fmt.Errorf("%w: invalid key state %d", ErrInvalidArgument, uint(s))
+	}
+
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state from its name. An empty name decodes as
+// KeyStateActive.
+func (s *KeyState) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = KeyStateActive
+		return nil
+	}
+
+	for state, name := range keyStateNames {
+		if name == string(text) {
+			*s = state
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: invalid key state %q", ErrInvalidArgument, text)
+}
 
 // Key represents a key with an ID, value, and expiration time. The ID is a unique
 // identifier for the key. The value is the actual key data. The expiration time
 // is the time at which the key expires.
+//
+// The State tracks the key along its lifecycle: pending keys are published
+// ahead of use, active keys are used for signing, retiring keys are only used
+// for verification and revoked keys are rejected everywhere. The timestamps
+// record when the key entered each state and are zero until it does.
 type Key struct {
 	ID      string    `json:"id"`
 	Value   any       `json:"value"`
 	Expires time.Time `json:"expires"`
+
+	State       KeyState  `json:"state"`
+	CreatedAt   time.Time `json:"created_at"`
+	ActivatedAt time.Time `json:"activated_at"`
+	RetiredAt   time.Time `json:"retired_at"`
+	RevokedAt   time.Time `json:"revoked_at"`
+}
+
+// keyJSON has the fields of Key without its methods.
+type keyJSON Key
+
+// MarshalJSON encodes the key as JSON, encoding its value with
+// MarshalKeyValue so that the value type survives the round trip.
+func (k Key) MarshalJSON() ([]byte, error) {
+	value, err := MarshalKeyValue(k.Value)
+	if err != nil {
+		return nil, fmt.Errorf("key %s value: %w", k.ID, err)
+	}
+
+	return json.Marshal(&struct {
+		*keyJSON
+		Value json.RawMessage `json:"value"`
+	}{
+		keyJSON: (*keyJSON)(&k),
+		Value:   value,
+	})
+}
+
+// UnmarshalJSON decodes a key encoded by MarshalJSON, decoding its value with
+// UnmarshalKeyValue. Values without a codec, such as the plain strings stored
+// by earlier versions, are decoded as plain JSON.
+func (k *Key) UnmarshalJSON(data []byte) error {
+	decoded := &struct {
+		*keyJSON
+		Value json.RawMessage `json:"value"`
+	}{
+		keyJSON: (*keyJSON)(k),
+	}
+
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+
+	value, err := UnmarshalKeyValue(decoded.Value)
+	if err != nil {
+		return fmt.Errorf("key %s value: %w", k.ID, err)
+	}
+
+	k.Value = value
+	return nil
 }
 
 // Expired checks if the key has expired. It returns true if the key's expiration
@@ -20,5 +143,32 @@ type Key struct {
 //	    fmt.Println("The key has not expired.")
 //	}
 func (k *Key) Expired() bool {
-	return k.Expires.Before(time.Now())
+	return k.ExpiredAt(time.Now())
+}
+
+// ExpiredAt checks if the key has expired at the given time.
+func (k *Key) ExpiredAt(now time.Time) bool {
+	return k.Expires.Before(now)
+}
+
+// CanSign checks if the key can be used for signing, that is, if it is active
+// and has not expired.
+func (k *Key) CanSign() bool {
+	return k.CanSignAt(time.Now())
+}
+
+// CanSignAt checks if the key can be used for signing at the given time.
+func (k *Key) CanSignAt(now time.Time) bool {
+	return k.State == KeyStateActive && !k.ExpiredAt(now)
+}
+
+// CanVerify checks if the key can be used for verification, that is, if it
+// has not been revoked and has not expired.
+func (k *Key) CanVerify() bool {
+	return k.CanVerifyAt(time.Now())
+}
+
+// CanVerifyAt checks if the key can be used for verification at the given time.
+func (k *Key) CanVerifyAt(now time.Time) bool {
+	return k.State != KeyStateRevoked && !k.ExpiredAt(now)
 }
@@ -0,0 +1,138 @@
+package krot
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyWrapper encrypts and decrypts the data encryption keys of an encrypted
+// KeyStorage under a key encryption key (KEK) it never discloses. It abstracts
+// local KEKs as well as key management services.
+type KeyWrapper interface {
+	// WrapKey encrypts the given data encryption key.
+	//
+	//     wrapped, err := wrapper.WrapKey(ctx, dataKey)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	WrapKey(context context.Context, key []byte) ([]byte, error)
+
+	// UnwrapKey decrypts a data encryption key encrypted by WrapKey.
+	//
+	//     dataKey, err := wrapper.UnwrapKey(ctx, wrapped)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	UnwrapKey(context context.Context, wrapped []byte) ([]byte, error)
+}
+
+type aesKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewAESKeyWrapper returns a KeyWrapper that encrypts data encryption keys with
+// AES-GCM under the given KEK, which must be 16, 24 or 32 bytes long.
+func NewAESKeyWrapper(kek []byte) (KeyWrapper, error) {
+	aead, err := newAESGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return &aesKeyWrapper{aead: aead}, nil
+}
+
+// NewFileKeyWrapper returns an AES KeyWrapper whose KEK is read, encoded in
+// base64, from the file at the given path.
+//
+//	head -c 32 /dev/urandom | base64 > kek.txt
+func NewFileKeyWrapper(path string) (KeyWrapper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := decodeKEK(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: KEK file %s: %w", ErrInvalidArgument, path, err)
+	}
+
+	return NewAESKeyWrapper(kek)
+}
+
+// NewEnvKeyWrapper returns an AES KeyWrapper whose KEK is read, encoded in
+// base64, from the environment variable with the given name.
+func NewEnvKeyWrapper(name string) (KeyWrapper, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: environment variable %s is not set", ErrInvalidArgument, name)
+	}
+
+	kek, err := decodeKEK(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: environment variable %s: %w", ErrInvalidArgument, name, err)
+	}
+
+	return NewAESKeyWrapper(kek)
+}
+
+func (w *aesKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return sealAESGCM(w.aead, key, nil)
+}
+
+func (w *aesKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return openAESGCM(w.aead, wrapped, nil)
+}
+
+func decodeKEK(encoded string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealAESGCM encrypts the plaintext under a random nonce, which is prepended to
+// the ciphertext.
+func sealAESGCM(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// openAESGCM decrypts a ciphertext produced by sealAESGCM.
+func openAESGCM(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrKeyDecryptionFailed
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKeyDecryptionFailed, err)
+	}
+
+	return plaintext, nil
+}
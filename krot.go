@@ -14,7 +14,9 @@ package krot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	cryptorand "crypto/rand"
@@ -40,6 +42,20 @@ func (h RotatorHooks) Run(rotator *Rotator) {
 	}
 }
 
+// RunContext runs the hooks in the collection until the context is done.
+// It returns the context's error if any hook was skipped.
+func (h RotatorHooks) RunContext(ctx context.Context, rotator *Rotator) error {
+	for _, hook := range h {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hook(rotator)
+	}
+
+	return nil
+}
+
 var (
 	// EraseStorageHook is a predefined function that implements the RotatorHook interface.
 	// When executed, it erases all keys from the associated Rotator's storage.
@@ -105,7 +121,8 @@ type RotatorSettings struct {
 	// The default value is DefaultRotationInterval.
 	RotationInterval time.Duration
 
-	// ExtendExpiration determines if the expiration of keys should be extended by the RotationInterval.
+	// ExtendExpiration determines if the expiration of keys should be extended until the next rotation,
+	// that is, by the RotationInterval unless a RotationSchedule is set.
 	// This ensures that there is always a valid key during rotation transitions.
 	// The default value is true.
 	ExtendExpiration bool
@@ -114,9 +131,55 @@ type RotatorSettings struct {
 	// The default value is true.
 	AutoClearExpiredKeys bool
 
-	// KeyProvidingMode is the strategy used for providing keys.
+	// KeyProvidingMode is the strategy used for providing keys, unless a KeySelector
+	// is set with SetKeySelector.
 	// The default value is AutoKeyProvidingMode.
 	KeyProvidingMode KeyProvidingMode
+
+	// MaxKeyUses is the number of times a key can be returned by GetKey and GetKeyFor before it is
+	// retired, e.g. to bound the number of encryptions made with AES-GCM under random nonces.
+	// Once the last active key is exhausted, the keys are rotated right away, ahead of schedule,
+	// and GetKey and GetKeyFor return an ErrKeyExhausted until the rotation succeeds.
+	// Followers leave the storage untouched: they reload the key set and, if their RotationLock
+	// implements RotationRequester, ask the leader to rotate.
+	// Uses are counted in memory by each rotator, and start over when it restarts.
+	// The default value, 0, sets no limit.
+	MaxKeyUses int
+
+	// PrePublishKeys determines if keys are published as pending one rotation before they become active.
+	// Pending keys can be fetched by verifiers before any signature is made with them.
+	// Their expiration is extended by the RotationInterval to cover the extra period.
+	// The default value is false.
+	PrePublishKeys bool
+
+	// RotateOnRevoke determines if an out-of-band rotation is triggered right after keys are revoked.
+	// The default value is false.
+	RotateOnRevoke bool
+
+	// RotationSchedule determines when rotations happen, e.g. NewCronSchedule("0 3 * * *", nil).
+	// The default value, nil, rotates every RotationInterval counting from the previous rotation.
+	RotationSchedule RotationSchedule
+
+	// RotationJitter is the upper bound of a random delay added to every scheduled rotation,
+	// spreading the rotations of a fleet of rotators sharing the same schedule.
+	// The default value is 0, which disables the jitter.
+	RotationJitter time.Duration
+
+	// Follower determines if the rotator never generates keys, and instead loads the active key set
+	// written by another rotator from its storage, which must then implement KeyLister.
+	// The key set is reloaded on every SyncInterval and, if the storage implements KeyWatcher,
+	// whenever the storage notifies a change.
+	// The default value is false.
+	Follower bool
+
+	// SyncInterval is the interval between reloads of the key set by followers, either in Follower
+	// mode or following the leader of a RotationLock.
+	// The default value, 0, reloads the key set on every scheduled rotation.
+	SyncInterval time.Duration
+
+	// Clock is the source of time of the rotator, its key cleaner and its in-memory storage.
+	// The default value, nil, uses the system time.
+	Clock Clock
 }
 
 // DefaultRotatorSettings returns the default rotator settings.
@@ -157,6 +220,30 @@ func (s *RotatorSettings) Validate() error {
 		)
 	}
 
+	if s.RotationJitter < 0 {
+		return fmt.Errorf(
+			"%w: rotation jitter must not be negative (got %s)",
+			ErrInvalidRotationSchedule,
+			s.RotationJitter,
+		)
+	}
+
+	if s.SyncInterval < 0 {
+		return fmt.Errorf(
+			"%w: sync interval must not be negative (got %s)",
+			ErrInvalidRotationSchedule,
+			s.SyncInterval,
+		)
+	}
+
+	if s.MaxKeyUses < 0 {
+		return fmt.Errorf(
+			"%w: max key uses must not be negative (got %d)",
+			ErrInvalidMaxKeyUses,
+			s.MaxKeyUses,
+		)
+	}
+
 	if s.KeyProvidingMode < AutoKeyProvidingMode ||
 		s.KeyProvidingMode > NonRepeatingCyclicKeyProvidingMode {
 		return fmt.Errorf(
@@ -200,116 +287,6 @@ const (
 	NonRepeatingCyclicKeyProvidingMode
 )
 
-// KeyIDProvider manages the provision of keys (IDs) based on a specified
-// strategy.
-type KeyIDProvider struct {
-	mode KeyProvidingMode
-
-	ids               []string
-	availableIndexes  []int
-	lastSelectedIndex int
-	round             int
-}
-
-// NewKeyIDProvider returns a new KeyIDProvider with the specified mode and IDs.
-func NewKeyIDProvider(mode KeyProvidingMode, ids ...string) *KeyIDProvider {
-	provider := &KeyIDProvider{mode: mode}
-	provider.Set(ids...)
-
-	return provider
-}
-
-// Set replaces the existing IDs in the KeyIDProvider with the provided IDs.
-// Note that any previous IDs are lost when this method is called.
-func (s *KeyIDProvider) Set(ids ...string) {
-	s.ids = ids
-	s.reloadAvailableIndexes()
-
-	if s.mode == AutoKeyProvidingMode {
-		switch len(s.ids) {
-		case 1:
-			s.mode = RandomKeyProvidingMode
-
-		case 2, 3, 4, 5:
-			s.mode = NonRepeatingKeyProvidingMode
-
-		default:
-			s.mode = NonRepeatingCyclicKeyProvidingMode
-		}
-	}
-}
-
-// Get returns an ID based on the current KeyProvidingMode. If no IDs are
-// available, it returns an error. The behavior varies depending on the mode:
-func (i *KeyIDProvider) Get() (string, error) {
-	if len(i.ids) == 0 {
-		return "", ErrNoKeysGenerated
-	}
-
-	if len(i.ids) == 1 {
-		return i.ids[0], nil
-	}
-
-	switch i.mode {
-	case RandomKeyProvidingMode:
-		return i.ids[mathrand.Intn(len(i.ids))], nil
-
-	case NonRepeatingKeyProvidingMode:
-		index := mathrand.Intn(len(i.ids) - 1)
-		if index >= i.lastSelectedIndex {
-			index++
-		}
-
-		i.lastSelectedIndex = index
-		return i.ids[index], nil
-
-	case CyclicKeyProvidingMode:
-		if i.round == len(i.ids) {
-			i.round = 0
-		}
-
-		id := i.ids[i.round]
-		i.round++
-
-		return id, nil
-
-	case NonRepeatingCyclicKeyProvidingMode:
-		if len(i.availableIndexes) == 0 {
-			i.reloadAvailableIndexes()
-		}
-
-		var index int
-		if len(i.availableIndexes) > 1 {
-			index = mathrand.Intn(len(i.availableIndexes) - 1)
-			if i.availableIndexes[index] == i.lastSelectedIndex {
-				index = len(i.availableIndexes) - 1
-			}
-		} else {
-			index = 0
-		}
-
-		i.lastSelectedIndex = i.availableIndexes[index]
-		id := i.ids[i.lastSelectedIndex]
-
-		i.availableIndexes[index] = i.availableIndexes[len(i.availableIndexes)-1]
-		i.availableIndexes[len(i.availableIndexes)-1] = i.lastSelectedIndex
-
-		i.availableIndexes = i.availableIndexes[:len(i.availableIndexes)-1]
-
-		return id, nil
-
-	default:
-		return "", fmt.Errorf("%w: %d", ErrInvalidKeyProvidingMode, i.mode)
-	}
-}
-
-func (i *KeyIDProvider) reloadAvailableIndexes() {
-	i.availableIndexes = make([]int, len(i.ids))
-	for index := range i.ids {
-		i.availableIndexes[index] = index
-	}
-}
-
 type rotatorContextKey struct {
 	alias string
 }
@@ -329,14 +306,30 @@ type Rotator struct {
 
 	storage    KeyStorage
 	generator  KeyGenerator
-	idProvider KeyIDProvider
+	selector   KeySelector
 	cleaner    KeyCleaner
+	lock       RotationLock
+	stateStore RotatorStateStore
+
+	leader         bool
+	replacing      bool
+	replacedAt     time.Time
+	customSelector bool
+
+	activeIDs   []string
+	pendingIDs  []string
+	keyUses     map[string]int
+	rotatedAt   time.Time
+	scheduledAt time.Time
+	published   []publishedKey
 
 	onStartHooks RotatorHooks
 	onStopHooks  RotatorHooks
 
 	hooksBeforeRotation RotatorHooks
 	hooksAfterRotation  RotatorHooks
+
+	subscribers subscribers
 }
 
 // New returns a newly initialized key rotator with default settings, storage, and key generator.
@@ -347,10 +340,11 @@ func New() *Rotator {
 		generator:  NewKeyGenerator(KeySize256),
 		storage:    NewKeyStorage(),
 		settings:   DefaultRotatorSettings(),
+		selector:   NewAutoKeySelector(),
 		controller: NewRotationController(),
 	}
 
-	rotator.cleaner = NewKeyCleaner(rotator.storage)
+	rotator.cleaner = rotator.newCleaner(rotator.storage)
 
 	return rotator
 }
@@ -369,7 +363,7 @@ func NewWithSettings(settings *RotatorSettings) (*Rotator, error) {
 		return nil, err
 	}
 
-	rotator.cleaner = NewKeyCleaner(rotator.storage)
+	rotator.cleaner = rotator.newCleaner(rotator.storage)
 
 	return rotator, nil
 }
@@ -528,6 +522,26 @@ func (r *Rotator) AutoClearExpiredKeys() bool {
 // It indicates whether the Rotator is configured to automatically clear expired keys.
 func AutoClearExpiredKeys() bool { return rotator.AutoClearExpiredKeys() }
 
+// PrePublishKeys returns the PrePublishKeys field of the Rotator's settings.
+// It indicates whether the Rotator publishes keys as pending one rotation before activating them.
+func (r *Rotator) PrePublishKeys() bool {
+	return r.settings.PrePublishKeys
+}
+
+// PrePublishKeys returns the PrePublishKeys field of the Rotator's settings.
+// It indicates whether the Rotator publishes keys as pending one rotation before activating them.
+func PrePublishKeys() bool { return rotator.PrePublishKeys() }
+
+// Clock returns the Clock field of the Rotator's settings, or the system clock if it is not set.
+// It is the source of time against which the Rotator expires its keys.
+func (r *Rotator) Clock() Clock {
+	return r.clock()
+}
+
+// GetClock returns the Clock field of the global Rotator's settings, or the system clock if it is not set.
+// It is the source of time against which the Rotator expires its keys.
+func GetClock() Clock { return rotator.Clock() }
+
 // SetSettings sets the settings field of the Rotator struct.
 // It accepts a RotatorSettings type as an argument and returns an error.
 // If the Rotator is currently active (i.e., r.status == RotatorStatusActive),
@@ -549,6 +563,14 @@ func (r *Rotator) SetSettings(settings *RotatorSettings) error {
 	}
 
 	r.settings = settings
+	r.shareClock(r.storage, r.cleaner, r.lock)
+
+	if !r.customSelector {
+		// Validated along with the settings.
+		r.selector, _ = NewKeySelector(settings.KeyProvidingMode)
+		r.selector.Set(r.activeIDs...)
+	}
+
 	return nil
 }
 
@@ -577,9 +599,11 @@ func (r *Rotator) SetStorage(storage KeyStorage) error {
 	}
 
 	r.cleaner.Stop()
-	r.cleaner = NewKeyCleaner(storage)
+	r.cleaner = r.newCleaner(storage)
 
 	r.storage = storage
+	r.shareClock(r.storage)
+
 	return nil
 }
 
@@ -618,6 +642,122 @@ func (r *Rotator) SetGenerator(generator KeyGenerator) error {
 // If the provided KeyGenerator is nil, the method returns an ErrInvalidArgument.
 func SetGenerator(generator KeyGenerator) error { return rotator.SetGenerator(generator) }
 
+// SetRotationLock sets the lock used to coordinate the Rotator with the rotators
+// of other replicas sharing its storage. Before each rotation, the Rotator tries
+// to acquire the lock's lease: if it succeeds, it becomes the leader and rotates
+// the keys; otherwise it becomes a follower and loads the current key set from
+// the storage instead, which must then implement KeyLister.
+// If the Rotator is currently active, the method immediately panics.
+// If the provided RotationLock is nil, the method returns an ErrInvalidArgument.
+func (r *Rotator) SetRotationLock(lock RotationLock) error {
+	if r.status == RotatorStatusStarted {
+		panic("cannot set rotation lock while rotator is running")
+	}
+
+	if lock == nil {
+		return fmt.Errorf("%w: rotation lock cannot be nil", ErrInvalidArgument)
+	}
+
+	r.lock = lock
+	r.shareClock(r.lock)
+
+	return nil
+}
+
+// SetRotationLock sets the lock used to coordinate the Rotator with the rotators
+// of other replicas sharing its storage. Before each rotation, the Rotator tries
+// to acquire the lock's lease: if it succeeds, it becomes the leader and rotates
+// the keys; otherwise it becomes a follower and loads the current key set from
+// the storage instead, which must then implement KeyLister.
+// If the Rotator is currently active, the method immediately panics.
+// If the provided RotationLock is nil, the method returns an ErrInvalidArgument.
+func SetRotationLock(lock RotationLock) error { return rotator.SetRotationLock(lock) }
+
+// SetStateStore sets the RotatorStateStore where the Rotator saves its metadata
+// after each rotation. On Start, the Rotator resumes from the saved snapshot as
+// long as its keys are still valid and its next rotation is not due yet,
+// instead of rotating immediately.
+//
+// Rotators following another one, in Follower mode or through a RotationLock,
+// load the key set from the storage instead and never resume.
+//
+// If the Rotator is running, it panics.
+// If the state store is nil, it returns an ErrInvalidArgument.
+func (r *Rotator) SetStateStore(store RotatorStateStore) error {
+	if r.status == RotatorStatusStarted {
+		panic("cannot set state store while rotator is running")
+	}
+
+	if store == nil {
+		return fmt.Errorf("%w: state store cannot be nil", ErrInvalidArgument)
+	}
+
+	r.stateStore = store
+	return nil
+}
+
+// SetStateStore sets the RotatorStateStore where the Rotator saves its metadata
+// after each rotation. On Start, the Rotator resumes from the saved snapshot as
+// long as its keys are still valid and its next rotation is not due yet,
+// instead of rotating immediately.
+//
+// Rotators following another one, in Follower mode or through a RotationLock,
+// load the key set from the storage instead and never resume.
+//
+// If the Rotator is running, it panics.
+// If the state store is nil, it returns an ErrInvalidArgument.
+func SetStateStore(store RotatorStateStore) error { return rotator.SetStateStore(store) }
+
+// SetKeySelector sets the KeySelector choosing the key handed out by GetKey
+// among the active keys, instead of the built-in selector of the
+// KeyProvidingMode setting. The selector is given the current active keys
+// right away.
+//
+// If the Rotator is running, it panics.
+// If the selector is nil, it returns an ErrInvalidArgument.
+func (r *Rotator) SetKeySelector(selector KeySelector) error {
+	if r.status == RotatorStatusStarted {
+		panic("cannot set key selector while rotator is running")
+	}
+
+	if selector == nil {
+		return fmt.Errorf("%w: key selector cannot be nil", ErrInvalidArgument)
+	}
+
+	r.controller.Lock()
+	defer r.controller.Unlock()
+
+	selector.Set(r.activeIDs...)
+	r.selector = selector
+	r.customSelector = true
+
+	return nil
+}
+
+// SetKeySelector sets the KeySelector choosing the key handed out by GetKey
+// among the active keys, instead of the built-in selector of the
+// KeyProvidingMode setting. The selector is given the current active keys
+// right away.
+//
+// If the Rotator is running, it panics.
+// If the selector is nil, it returns an ErrInvalidArgument.
+func SetKeySelector(selector KeySelector) error { return rotator.SetKeySelector(selector) }
+
+// Leader reports whether the Rotator generated the current keys itself, as
+// opposed to following the leader of its RotationLock or running in Follower
+// mode. Other rotators always lead.
+func (r *Rotator) Leader() bool {
+	r.controller.Lock()
+	defer r.controller.Unlock()
+
+	return r.leading()
+}
+
+// Leader reports whether the Rotator generated the current keys itself, as
+// opposed to following the leader of its RotationLock or running in Follower
+// mode. Other rotators always lead.
+func Leader() bool { return rotator.Leader() }
+
 // OnStart appends provided hooks that can be called when the Rotator starts.
 func (r *Rotator) OnStart(hooks ...RotatorHook) {
 	r.onStartHooks = append(r.onStartHooks, hooks...)
@@ -654,13 +794,33 @@ func (r *Rotator) AfterRotation(hooks ...RotatorHook) {
 // These hooks are executed after a rotation occurs.
 func AfterRotation(hooks ...RotatorHook) { rotator.AfterRotation(hooks...) }
 
+// Subscribe returns a channel that receives the events of the Rotator, such as
+// KeysRotated or KeysRevoked, until the context is done, when it is closed.
+// Events are sent without blocking: a subscriber that falls
+// RotatorEventBufferSize events behind misses the following ones.
+//
+//	for event := range rotator.Subscribe(ctx) {
+//	    if rotated, ok := event.(krot.KeysRotated); ok {
+//	        cache.Evict(rotated.Retired...)
+//	    }
+//	}
+func (r *Rotator) Subscribe(ctx context.Context) <-chan RotatorEvent {
+	return r.subscribers.add(ctx)
+}
+
+// Subscribe returns a channel that receives the events of the Rotator, such as
+// KeysRotated or KeysRevoked, until the context is done, when it is closed.
+// Events are sent without blocking: a subscriber that falls
+// RotatorEventBufferSize events behind misses the following ones.
+func Subscribe(ctx context.Context) <-chan RotatorEvent { return rotator.Subscribe(ctx) }
+
 // GetKeyID retrieves a random key ID from the Rotator.
 // It returns the retrieved key ID and any error that occurred.
 func (r *Rotator) GetKeyID() (string, error) {
 	r.controller.Lock()
 	defer r.controller.Unlock()
 
-	return r.idProvider.Get()
+	return r.selector.Select()
 }
 
 // GetKeyID retrieves a random key ID from the Rotator.
@@ -670,175 +830,941 @@ func GetKeyID() (string, error) { return rotator.GetKeyID() }
 // GetKeyByID retrieves a key from the Rotator's storage by its ID.
 // It returns the retrieved key and any error that occurred.
 func (r *Rotator) GetKeyByID(id string) (*Key, error) {
-	r.controller.Lock()
-	defer r.controller.Unlock()
-
-	key, err := r.storage.Get(context.Background(), id)
-	if err != nil {
-		return nil, err
-	}
-
-	return key, nil
+	return r.GetKeyByIDContext(context.Background(), id)
 }
 
 // GetKeyByID retrieves a key from the Rotator's storage by its ID.
 // It returns the retrieved key and any error that occurred.
 func GetKeyByID(id string) (*Key, error) { return rotator.GetKeyByID(id) }
 
-// GetKey retrieves a random key from the Rotator's storage.
+// GetKeyByIDContext retrieves a key from the Rotator's storage by its ID,
+// passing the given context to the storage.
 // It returns the retrieved key and any error that occurred.
-func (r *Rotator) GetKey() (*Key, error) {
+func (r *Rotator) GetKeyByIDContext(ctx context.Context, id string) (*Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.controller.Lock()
 	defer r.controller.Unlock()
 
-	id, err := r.idProvider.Get()
+	key, err := r.storage.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	key, err := r.storage.Get(context.Background(), id)
-	if err != nil {
-		return nil, err
+	if key.State == KeyStateRevoked {
+		return nil, fmt.Errorf("%w: key %s was revoked", ErrKeyRevoked, id)
 	}
 
 	return key, nil
 }
 
+// GetKeyByIDContext retrieves a key from the Rotator's storage by its ID,
+// passing the given context to the storage.
+// It returns the retrieved key and any error that occurred.
+func GetKeyByIDContext(ctx context.Context, id string) (*Key, error) {
+	return rotator.GetKeyByIDContext(ctx, id)
+}
+
+// GetKey retrieves a random key from the Rotator's storage.
+// It returns the retrieved key and any error that occurred.
+func (r *Rotator) GetKey() (*Key, error) {
+	return r.GetKeyContext(context.Background())
+}
+
 // GetKey retrieves a random key from the Rotator's storage.
 // It returns the retrieved key and any error that occurred.
 func GetKey() (*Key, error) { return rotator.GetKey() }
 
-// Rotate generates a new set of keys and stores them in the Rotator's storage.
-// It first sets the Rotator's state to Rotating, runs any BeforeRotation hooks,
-// and then generates and stores the new keys.
-// After storing the keys, it runs any AfterRotation hooks and sets the state back to Idle.
-// It returns any error that occurred during the process.
-func (r *Rotator) Rotate() error {
-	r.hooksBeforeRotation.Run(r)
+// GetKeyContext retrieves a random key from the Rotator's storage,
+// passing the given context to the storage.
+// It returns the retrieved key and any error that occurred.
+func (r *Rotator) GetKeyContext(ctx context.Context) (*Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	var err error
-	defer func() {
-		if err == nil {
-			r.hooksAfterRotation.Run(r)
+	return r.useKey(ctx, func() (string, error) {
+		return r.selector.Select()
+	})
+}
+
+// useKey returns the active key whose ID is picked by the given function among
+// the signing pool, counting its use. If the use exhausts the budget of the
+// last active key, the key is replaced right away. Until its replacement is
+// active, the exhausted key is never returned: useKey attempts the replacement
+// again, at most once every rotationRetryDelay, and returns an ErrKeyExhausted
+// if it is still pending.
+func (r *Rotator) useKey(ctx context.Context, pick func() (string, error)) (*Key, error) {
+	retried := false
+	for {
+		r.controller.Lock()
+
+		key, err := r.activeKey(ctx, pick)
+		if err != nil {
+			r.controller.Unlock()
+			return nil, err
 		}
-	}()
 
-	r.controller.Lock()
-	defer r.controller.Unlock()
+		if r.exhausted(key.ID) && !r.lastUsable(key.ID) {
+			// A follower reloaded a key it had retired on its own.
+			r.removeActiveIDs(key.ID)
+			r.controller.Unlock()
+			continue
+		}
 
-	r.setState(RotatorStateRotating)
-	defer r.setState(RotatorStateIdle)
+		if r.exhausted(key.ID) {
+			replace := !retried && r.startReplacement(true)
+			r.controller.Unlock()
 
-	ids := make([]string, r.settings.RotationKeyCount)
-	keys := make([]*Key, r.settings.RotationKeyCount)
-	for i := 0; i < r.settings.RotationKeyCount; i++ {
-		keyID := make([]byte, KeySize64)
-		if _, err := cryptorand.Read(keyID); err != nil {
-			return err
+			if !replace {
+				return nil, fmt.Errorf("%w: key %s reached its %d uses and is not replaced yet",
+					ErrKeyExhausted, key.ID, r.settings.MaxKeyUses)
+			}
+
+			r.replaceKeys(context.WithoutCancel(ctx))
+			retried = true
+			continue
 		}
 
-		keyValue, err := r.generator.Generate()
+		exhausted, err := r.countUse(ctx, key)
+		replace := exhausted && r.startReplacement(false)
+		r.controller.Unlock()
+
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		keyExpiration := time.Now().Add(r.settings.KeyExpiration)
-		if r.settings.ExtendExpiration {
-			keyExpiration = keyExpiration.Add(r.settings.RotationInterval)
+		if replace {
+			r.replaceKeys(context.WithoutCancel(ctx))
 		}
 
-		key := &Key{
-			ID:      fmt.Sprintf("%s:%x", r.id, keyID),
-			Value:   keyValue,
-			Expires: keyExpiration,
-		}
+		return key, nil
+	}
+}
 
-		keys[i] = key
-		ids[i] = key.ID
+// exhausted reports whether the key has been used MaxKeyUses times.
+// The controller must be locked.
+func (r *Rotator) exhausted(id string) bool {
+	return r.settings.MaxKeyUses > 0 && r.keyUses[id] >= r.settings.MaxKeyUses
+}
+
+// lastUsable reports whether no other active key is left within its budget.
+// The controller must be locked.
+func (r *Rotator) lastUsable(id string) bool {
+	return !slices.ContainsFunc(r.activeIDs, func(other string) bool {
+		return other != id && !r.exhausted(other)
+	})
+}
+
+// startReplacement reports whether the caller should replace the exhausted
+// signing pool, marking the replacement as in progress. Retries wait for
+// rotationRetryDelay since the previous attempt, so that a failing rotation or
+// a follower waiting for its leader does not hit the storage on every use.
+// The controller must be locked.
+func (r *Rotator) startReplacement(retry bool) bool {
+	if r.replacing {
+		return false
 	}
 
-	err = r.storage.Add(context.Background(), keys...)
-	if err != nil {
-		return err
+	now := r.now()
+	if retry && now.Sub(r.replacedAt) < rotationRetryDelay {
+		return false
 	}
 
-	r.idProvider.Set(ids...)
-	return nil
+	r.replacing = true
+	r.replacedAt = now
+	return true
 }
 
-// Rotate generates a new set of keys and stores them in the Rotator's storage.
-// It first sets the Rotator's state to Rotating, runs any BeforeRotation hooks,
-// and then generates and stores the new keys.
-// After storing the keys, it runs any AfterRotation hooks and sets the state back to Idle.
-// It returns any error that occurred during the process.
-func Rotate() error { return rotator.Rotate() }
+// countUse counts a use of the key and, once it reaches MaxKeyUses, retires
+// the key. The last active key stays in the signing pool until it is replaced,
+// so that followers keep a key set to reload: countUse reports whether it is
+// exhausted. Only the leader writes the retired keys to the storage, and uses
+// are remembered until the key leaves the active keys, so that followers do
+// not reset the budget of the keys they reload.
+// The controller must be locked.
+func (r *Rotator) countUse(ctx context.Context, key *Key) (bool, error) {
+	if r.settings.MaxKeyUses <= 0 {
+		return false, nil
+	}
 
-// Start initiates the key rotation process. If components like the key generator,
-// storage, rotation settings, rotation controller, or key cleaner are not set,
-// they are initialized with default values. The Rotator's status is then set to
-// active, and the rotation and cleaning processes are launched in separate goroutines.
-//
-// If the Rotator is already active when Start is called, it returns an
-// ErrRotatorAlreadyRunning error. If an error occurs during the initial key rotation,
-// the error is returned and the Rotator does not start.
-//
-// This method is safe for concurrent use.
-//
-// Example:
-//
-//	rotator := NewRotator()
-//	err := rotator.Start()
-//	if err != nil {
-//	    log.Fatal(err)
-//	}
-//	defer rotator.Stop()
-//
-// If the Rotator starts successfully, Start returns nil.
-func (r *Rotator) Start() error {
-	if r.status == RotatorStatusStarted {
-		return ErrRotatorAlreadyRunning
+	if r.keyUses == nil {
+		r.keyUses = map[string]int{}
 	}
 
-	if r.settings.AutoClearExpiredKeys {
-		interval := r.settings.KeyExpiration + time.Second
-		r.cleaner.Start(context.Background(), interval)
+	r.keyUses[key.ID]++
+	if r.keyUses[key.ID] < r.settings.MaxKeyUses {
+		return false, nil
 	}
 
-	r.controller.TurnOn()
-	if err := r.Rotate(); err != nil {
-		return err
+	if r.lastUsable(key.ID) {
+		return true, nil
+	}
+
+	if r.leading() {
+		retiring, err := r.transitionKeys(ctx, []string{key.ID}, KeyStateRetiring, r.now())
+		if err != nil {
+			return false, err
+		}
+
+		if err := r.storage.Add(ctx, retiring...); err != nil {
+			return false, err
+		}
 	}
 
-	go r.run()
+	r.removeActiveIDs(key.ID)
 
-	r.setStatus(RotatorStatusStarted)
-	r.onStartHooks.Run(r)
+	r.subscribers.emit(KeysRotated{Retired: []string{key.ID}})
 
-	return nil
+	return false, nil
 }
 
-// Start initiates the key rotation process. If components like the key generator,
-// storage, rotation settings, rotation controller, or key cleaner are not set,
-// they are initialized with default values. The Rotator's status is then set to
-// active, and the rotation and cleaning processes are launched in separate goroutines.
-//
-// If the Rotator is already active when Start is called, it returns an
-// ErrRotatorAlreadyRunning error. If an error occurs during the initial key rotation,
-// the error is returned and the Rotator does not start.
-//
-// This method is safe for concurrent use.
-//
-// Example:
-//
-//	rotator := NewRotator()
-//	err := rotator.Start()
-//	if err != nil {
-//	    log.Fatal(err)
-//	}
-//	defer rotator.Stop()
-//
-// If the Rotator starts successfully, Start returns nil.
-func Start() error { return rotator.Start() }
+// replaceKeys replaces the exhausted signing pool. The leader rotates right
+// away, while other rotators ask the leader to rotate, if their RotationLock
+// implements RotationRequester, and reload the key set. Failures are reported
+// as RotationFailed events, and the replacement is attempted again by a later
+// use of the exhausted key.
+func (r *Rotator) replaceKeys(ctx context.Context) {
+	defer func() {
+		r.controller.Lock()
+		r.replacing = false
+		r.controller.Unlock()
+	}()
+
+	r.controller.Lock()
+	leading := r.leading()
+	requester, ok := r.lock.(RotationRequester)
+	r.controller.Unlock()
+
+	if !leading && ok {
+		if err := requester.RequestRotation(ctx); err != nil {
+			r.subscribers.emit(RotationFailed{Err: err})
+			return
+		}
+	}
+
+	r.RotateContext(ctx)
+}
+
+// pruneKeyUses forgets the uses of the keys that left the signing pool.
+// The controller must be locked.
+func (r *Rotator) pruneKeyUses() {
+	for id := range r.keyUses {
+		if !slices.Contains(r.activeIDs, id) {
+			delete(r.keyUses, id)
+		}
+	}
+}
+
+// activeKey returns the active key whose ID is picked by the given function
+// among the signing pool.
+// The controller must be locked.
+func (r *Rotator) activeKey(ctx context.Context, pick func() (string, error)) (*Key, error) {
+	reloaded := false
+	for {
+		id, err := pick()
+		if errors.Is(err, ErrNoKeysGenerated) && r.following() && !reloaded {
+			// The leader may have rotated since the key set was last loaded.
+			if err := r.loadKeyIDs(ctx); err != nil {
+				return nil, err
+			}
+
+			r.selector.Set(r.activeIDs...)
+			reloaded = true
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := r.storage.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if key.State == KeyStateActive {
+			return key, nil
+		}
+
+		// The key has left the active state behind the rotator's back, e.g.
+		// revoked by another instance sharing the storage.
+		r.removeActiveIDs(id)
+	}
+}
+
+// GetKeyFor retrieves the key of the given subject, e.g. a user ID, from the
+// Rotator's storage. Subjects are mapped onto the active keys by rendezvous
+// hashing, so a subject keeps getting the same key until the next rotation,
+// and only the subjects of removed keys are remapped when the key set changes.
+// It returns the retrieved key and any error that occurred.
+func (r *Rotator) GetKeyFor(subject string) (*Key, error) {
+	return r.GetKeyForContext(context.Background(), subject)
+}
+
+// GetKeyFor retrieves the key of the given subject, e.g. a user ID, from the
+// Rotator's storage. Subjects are mapped onto the active keys by rendezvous
+// hashing, so a subject keeps getting the same key until the next rotation,
+// and only the subjects of removed keys are remapped when the key set changes.
+// It returns the retrieved key and any error that occurred.
+func GetKeyFor(subject string) (*Key, error) { return rotator.GetKeyFor(subject) }
+
+// GetKeyForContext retrieves the key of the given subject from the Rotator's
+// storage, passing the given context to the storage.
+// It returns the retrieved key and any error that occurred.
+func (r *Rotator) GetKeyForContext(ctx context.Context, subject string) (*Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.useKey(ctx, func() (string, error) {
+		return rendezvous(subject, r.activeIDs)
+	})
+}
+
+// GetKeyForContext retrieves the key of the given subject from the Rotator's
+// storage, passing the given context to the storage.
+// It returns the retrieved key and any error that occurred.
+func GetKeyForContext(ctx context.Context, subject string) (*Key, error) {
+	return rotator.GetKeyForContext(ctx, subject)
+}
+
+// removeActiveIDs removes the given IDs from the signing pool.
+// The controller must be locked.
+func (r *Rotator) removeActiveIDs(ids ...string) {
+	r.activeIDs = slices.DeleteFunc(slices.Clone(r.activeIDs), func(id string) bool {
+		return slices.Contains(ids, id)
+	})
+
+	r.selector.Set(r.activeIDs...)
+}
+
+// GetKeyContext retrieves a random key from the Rotator's storage,
+// passing the given context to the storage.
+// It returns the retrieved key and any error that occurred.
+func GetKeyContext(ctx context.Context) (*Key, error) { return rotator.GetKeyContext(ctx) }
+
+// Rotate generates a new set of keys and stores them in the Rotator's storage.
+// It first sets the Rotator's state to Rotating, runs any BeforeRotation hooks,
+// and then generates and stores the new keys.
+// After storing the keys, it runs any AfterRotation hooks and sets the state back to Idle.
+// It returns any error that occurred during the process.
+func (r *Rotator) Rotate() error {
+	return r.RotateContext(context.Background())
+}
+
+// Rotate generates a new set of keys and stores them in the Rotator's storage.
+// It first sets the Rotator's state to Rotating, runs any BeforeRotation hooks,
+// and then generates and stores the new keys.
+// After storing the keys, it runs any AfterRotation hooks and sets the state back to Idle.
+// It returns any error that occurred during the process.
+func Rotate() error { return rotator.Rotate() }
+
+// RotateContext works like Rotate, passing the given context to the storage.
+// Key generation, storage and the remaining hooks are abandoned as soon as
+// the context is done, in which case the context's error is returned.
+func (r *Rotator) RotateContext(ctx context.Context) error {
+	err := r.hooksBeforeRotation.RunContext(ctx, r)
+	if err == nil {
+		err = r.rotate(ctx)
+	}
+
+	if err == nil {
+		err = r.hooksAfterRotation.RunContext(ctx, r)
+	}
+
+	if err != nil {
+		r.subscribers.emit(RotationFailed{Err: err})
+	}
+
+	return err
+}
+
+// RotateContext works like Rotate, passing the given context to the storage.
+// Key generation, storage and the remaining hooks are abandoned as soon as
+// the context is done, in which case the context's error is returned.
+func RotateContext(ctx context.Context) error { return rotator.RotateContext(ctx) }
+
+func (r *Rotator) rotate(ctx context.Context) error {
+	r.controller.Lock()
+	defer r.controller.Unlock()
+
+	r.setState(RotatorStateRotating)
+	defer r.setState(RotatorStateIdle)
+
+	now := r.now()
+	next := r.scheduleAfter(now)
+
+	if r.settings.Follower {
+		return r.follow(ctx, now, next)
+	}
+
+	if r.lock != nil {
+		// Leases last for one and a half periods, so that the leader renews its
+		// lease before it expires.
+		lease := next.Sub(now) * 3 / 2
+
+		leader, err := r.lock.Acquire(ctx, r.id, lease)
+		if err != nil {
+			return err
+		}
+
+		r.leader = leader
+		if !leader {
+			return r.follow(ctx, now, next)
+		}
+
+		if err := r.loadKeyIDs(ctx); err != nil {
+			return err
+		}
+	}
+
+	retiring, err := r.transitionKeys(ctx, r.activeIDs, KeyStateRetiring, now)
+	if err != nil {
+		return err
+	}
+
+	active, err := r.transitionKeys(ctx, r.pendingIDs, KeyStateActive, now)
+	if err != nil {
+		return err
+	}
+
+	activeExpiration := now.Add(r.settings.KeyExpiration)
+	pendingExpiration := next.Add(r.settings.KeyExpiration)
+	if r.settings.ExtendExpiration {
+		activeExpiration = activeExpiration.Add(next.Sub(now))
+		pendingExpiration = pendingExpiration.Add(r.scheduleAfter(next).Sub(next))
+	}
+
+	var generated []*Key
+	if len(active) == 0 {
+		active, err = r.generateKeys(ctx, KeyStateActive, now, activeExpiration)
+		if err != nil {
+			return err
+		}
+
+		generated = append(generated, active...)
+	}
+
+	var pending []*Key
+	if r.settings.PrePublishKeys {
+		pending, err = r.generateKeys(ctx, KeyStatePending, now, pendingExpiration)
+		if err != nil {
+			return err
+		}
+
+		generated = append(generated, pending...)
+	}
+
+	keys := append(append(retiring, active...), pending...)
+	if err := r.storage.Add(ctx, keys...); err != nil {
+		return err
+	}
+
+	r.activeIDs = keyIDs(active)
+	r.pendingIDs = keyIDs(pending)
+	r.selector.Set(r.activeIDs...)
+	r.pruneKeyUses()
+
+	r.publish(now, generated...)
+	r.rotatedAt = now
+	r.scheduledAt = next
+
+	r.subscribers.emit(KeysRotated{Added: keyIDs(generated), Retired: keyIDs(retiring)})
+
+	return r.saveState(ctx)
+}
+
+// saveState saves the Rotator's metadata in its state store, if any.
+// The controller must be locked.
+func (r *Rotator) saveState(ctx context.Context) error {
+	if r.stateStore == nil {
+		return nil
+	}
+
+	return r.stateStore.Save(ctx, &RotatorSnapshot{
+		ActiveKeyIDs:   r.activeIDs,
+		PendingKeyIDs:  r.pendingIDs,
+		RotatedAt:      r.rotatedAt,
+		ScheduledAt:    r.scheduledAt,
+		ProviderCursor: selectorCursor(r.selector),
+	})
+}
+
+// selectorCursor returns the position of the selector in the active keys, if
+// it goes through them in order.
+func selectorCursor(selector KeySelector) int {
+	if cursor, ok := selector.(keySelectorCursor); ok {
+		return cursor.cursor()
+	}
+
+	return 0
+}
+
+// resume restores the Rotator's metadata from its state store, if any, and
+// reports whether it did. Snapshots whose next rotation is due, or whose active
+// keys are no longer valid for signing, are ignored.
+func (r *Rotator) resume(ctx context.Context) (bool, error) {
+	r.controller.Lock()
+	defer r.controller.Unlock()
+
+	if r.stateStore == nil || r.following() {
+		return false, nil
+	}
+
+	snapshot, err := r.stateStore.Load(ctx)
+	if errors.Is(err, ErrStateNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	now := r.now()
+	if len(snapshot.ActiveKeyIDs) == 0 || !now.Before(snapshot.ScheduledAt) {
+		return false, nil
+	}
+
+	resumed := make([]*Key, 0, len(snapshot.ActiveKeyIDs)+len(snapshot.PendingKeyIDs))
+	for _, id := range snapshot.ActiveKeyIDs {
+		key, err := r.storage.Get(ctx, id)
+		if errors.Is(err, ErrKeyNotFound) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if !key.CanSignAt(now) {
+			return false, nil
+		}
+
+		resumed = append(resumed, key)
+	}
+
+	pendingIDs := make([]string, 0, len(snapshot.PendingKeyIDs))
+	for _, id := range snapshot.PendingKeyIDs {
+		key, err := r.storage.Get(ctx, id)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if key.State == KeyStatePending && !key.ExpiredAt(now) {
+			pendingIDs = append(pendingIDs, id)
+			resumed = append(resumed, key)
+		}
+	}
+
+	r.activeIDs = snapshot.ActiveKeyIDs
+	r.pendingIDs = pendingIDs
+	r.selector.Set(r.activeIDs...)
+	if cursor, ok := r.selector.(keySelectorCursor); ok {
+		cursor.seek(snapshot.ProviderCursor)
+	}
+
+	r.publish(now, resumed...)
+
+	r.rotatedAt = snapshot.RotatedAt
+	r.scheduledAt = snapshot.ScheduledAt
+
+	return true, nil
+}
+
+// leading reports whether the Rotator generated the current keys itself.
+// The controller must be locked.
+func (r *Rotator) leading() bool {
+	if r.settings.Follower {
+		return false
+	}
+
+	return r.lock == nil || r.leader
+}
+
+// following reports whether the Rotator may be using keys generated by another
+// rotator, either in Follower mode or through a RotationLock.
+func (r *Rotator) following() bool {
+	return r.settings.Follower || r.lock != nil
+}
+
+// follow loads the key set from the storage instead of rotating it, and
+// schedules the next reload.
+// The controller must be locked.
+func (r *Rotator) follow(ctx context.Context, now, next time.Time) error {
+	known := append(slices.Clone(r.activeIDs), r.pendingIDs...)
+	active := slices.Clone(r.activeIDs)
+
+	if err := r.loadKeyIDs(ctx); err != nil {
+		return err
+	}
+
+	r.selector.Set(r.activeIDs...)
+	r.pruneKeyUses()
+
+	var rotated KeysRotated
+	for _, id := range append(slices.Clone(r.activeIDs), r.pendingIDs...) {
+		if !slices.Contains(known, id) {
+			rotated.Added = append(rotated.Added, id)
+		}
+	}
+
+	for _, id := range active {
+		if !slices.Contains(r.activeIDs, id) {
+			rotated.Retired = append(rotated.Retired, id)
+		}
+	}
+
+	if len(rotated.Added) > 0 || len(rotated.Retired) > 0 {
+		r.subscribers.emit(rotated)
+	}
+
+	r.scheduledAt = next
+	if r.settings.SyncInterval > 0 && now.Add(r.settings.SyncInterval).Before(next) {
+		r.scheduledAt = now.Add(r.settings.SyncInterval)
+	}
+
+	return nil
+}
+
+// watch rotates, or reloads the key set, whenever the storage notifies a change
+// or a follower requests a rotation, until the channel is closed.
+func (r *Rotator) watch(ctx context.Context, changes <-chan struct{}) {
+	for range changes {
+		r.RotateContext(ctx)
+	}
+}
+
+// loadKeyIDs loads the IDs of the active and pending keys from the storage,
+// replacing the ones generated by the Rotator itself.
+// The controller must be locked.
+func (r *Rotator) loadKeyIDs(ctx context.Context) error {
+	lister, ok := r.storage.(KeyLister)
+	if !ok {
+		return fmt.Errorf("%w: storage must implement KeyLister to load keys", ErrUnsupportedStorage)
+	}
+
+	keys, err := lister.List(ctx, &KeyListOptions{
+		States: []KeyState{KeyStateActive, KeyStatePending},
+	})
+	if err != nil {
+		return err
+	}
+
+	r.activeIDs = r.activeIDs[:0:0]
+	r.pendingIDs = r.pendingIDs[:0:0]
+	for _, key := range keys {
+		if key.State == KeyStateActive {
+			r.activeIDs = append(r.activeIDs, key.ID)
+		} else {
+			r.pendingIDs = append(r.pendingIDs, key.ID)
+		}
+	}
+
+	return nil
+}
+
+// scheduleAfter returns the time of the first rotation after the given time,
+// following the RotationSchedule if any and adding the RotationJitter.
+func (r *Rotator) scheduleAfter(after time.Time) time.Time {
+	var next time.Time
+	if r.settings.RotationSchedule != nil {
+		next = r.settings.RotationSchedule.Next(after)
+	}
+
+	if next.IsZero() {
+		next = after.Add(r.settings.RotationInterval)
+	}
+
+	if r.settings.RotationJitter > 0 {
+		next = next.Add(time.Duration(mathrand.Int63n(int64(r.settings.RotationJitter))))
+	}
+
+	return next
+}
+
+// generateKeys generates a batch of RotationKeyCount keys in the given state.
+func (r *Rotator) generateKeys(ctx context.Context, state KeyState, now, expiration time.Time) ([]*Key, error) {
+	keys := make([]*Key, r.settings.RotationKeyCount)
+	for i := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		keyID := make([]byte, KeySize64)
+		if _, err := cryptorand.Read(keyID); err != nil {
+			return nil, err
+		}
+
+		keyValue, err := r.generator.Generate()
+		if err != nil {
+			return nil, err
+		}
+
+		key := &Key{
+			ID:        fmt.Sprintf("%s:%x", r.id, keyID),
+			Value:     keyValue,
+			Expires:   expiration,
+			State:     state,
+			CreatedAt: now,
+		}
+
+		if state == KeyStateActive {
+			key.ActivatedAt = now
+		}
+
+		keys[i] = key
+	}
+
+	return keys, nil
+}
+
+// transitionKeys returns copies of the stored keys with the given IDs moved to
+// the given state. Keys that are missing, expired or revoked are skipped.
+func (r *Rotator) transitionKeys(ctx context.Context, ids []string, state KeyState, now time.Time) ([]*Key, error) {
+	keys := make([]*Key, 0, len(ids))
+	for _, id := range ids {
+		key, err := r.storage.Get(ctx, id)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if key.State == KeyStateRevoked || key.ExpiredAt(now) {
+			continue
+		}
+
+		transitioned := *key
+		transitioned.State = state
+
+		switch state {
+		case KeyStateActive:
+			transitioned.ActivatedAt = now
+
+		case KeyStateRetiring:
+			transitioned.RetiredAt = now
+
+		case KeyStateRevoked:
+			transitioned.RevokedAt = now
+		}
+
+		keys = append(keys, &transitioned)
+	}
+
+	return keys, nil
+}
+
+func keyIDs(keys []*Key) []string {
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ID
+	}
+
+	return ids
+}
+
+// Revoke marks the keys with the given IDs as revoked in the Rotator's storage
+// and removes them from the signing pool immediately. Revoked keys are no longer
+// returned by GetKey, and GetKeyByID returns an ErrKeyRevoked for them.
+// IDs of keys that are missing or already expired are ignored.
+//
+// If RotateOnRevoke is set, a rotation is triggered right after the revocation
+// so that the signing pool is refilled with fresh keys.
+//
+//	err := rotator.Revoke(ctx, leakedKeyID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func (r *Rotator) Revoke(ctx context.Context, ids ...string) error {
+	revoked, err := r.revoke(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	if revoked > 0 && r.settings.RotateOnRevoke {
+		return r.RotateContext(ctx)
+	}
+
+	return nil
+}
+
+// Revoke marks the keys with the given IDs as revoked in the Rotator's storage
+// and removes them from the signing pool immediately. Revoked keys are no longer
+// returned by GetKey, and GetKeyByID returns an ErrKeyRevoked for them.
+// IDs of keys that are missing or already expired are ignored.
+//
+// If RotateOnRevoke is set, a rotation is triggered right after the revocation
+// so that the signing pool is refilled with fresh keys.
+func Revoke(ctx context.Context, ids ...string) error { return rotator.Revoke(ctx, ids...) }
+
+// RevokeAll revokes every non-expired key returned by Keys, emptying the
+// signing pool. Unless RotateOnRevoke is set, GetKey fails until the next
+// rotation.
+func (r *Rotator) RevokeAll(ctx context.Context) error {
+	keys, err := r.Keys(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.controller.Lock()
+	ids := append(keyIDs(keys), r.activeIDs...)
+	r.controller.Unlock()
+
+	return r.Revoke(ctx, ids...)
+}
+
+// RevokeAll revokes every non-expired key returned by Keys, emptying the
+// signing pool. Unless RotateOnRevoke is set, GetKey fails until the next
+// rotation.
+func RevokeAll(ctx context.Context) error { return rotator.RevokeAll(ctx) }
+
+// revoke marks the keys as revoked and returns how many of them were.
+func (r *Rotator) revoke(ctx context.Context, ids []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.controller.Lock()
+	defer r.controller.Unlock()
+
+	r.removeActiveIDs(ids...)
+	r.pendingIDs = slices.DeleteFunc(slices.Clone(r.pendingIDs), func(id string) bool {
+		return slices.Contains(ids, id)
+	})
+
+	keys, err := r.transitionKeys(ctx, ids, KeyStateRevoked, r.now())
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.storage.Add(ctx, keys...); err != nil {
+		return 0, err
+	}
+
+	if len(keys) > 0 {
+		r.subscribers.emit(KeysRevoked{IDs: keyIDs(keys)})
+	}
+
+	return len(keys), nil
+}
+
+// Start initiates the key rotation process. If components like the key generator,
+// storage, rotation settings, rotation controller, or key cleaner are not set,
+// they are initialized with default values. The Rotator's status is then set to
+// active, and the rotation and cleaning processes are launched in separate goroutines.
+//
+// If the Rotator is already active when Start is called, it returns an
+// ErrRotatorAlreadyRunning error. If an error occurs during the initial key rotation,
+// the error is returned and the Rotator does not start.
+//
+// This method is safe for concurrent use.
+//
+// Example:
+//
+//	rotator := NewRotator()
+//	err := rotator.Start()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer rotator.Stop()
+//
+// If the Rotator starts successfully, Start returns nil.
+func (r *Rotator) Start() error {
+	return r.StartContext(context.Background())
+}
+
+// Start initiates the key rotation process. If components like the key generator,
+// storage, rotation settings, rotation controller, or key cleaner are not set,
+// they are initialized with default values. The Rotator's status is then set to
+// active, and the rotation and cleaning processes are launched in separate goroutines.
+//
+// If the Rotator is already active when Start is called, it returns an
+// ErrRotatorAlreadyRunning error. If an error occurs during the initial key rotation,
+// the error is returned and the Rotator does not start.
+//
+// This method is safe for concurrent use.
+//
+// Example:
+//
+//	rotator := NewRotator()
+//	err := rotator.Start()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer rotator.Stop()
+//
+// If the Rotator starts successfully, Start returns nil.
+func Start() error { return rotator.Start() }
+
+// StartContext works like Start, using the given context for the initial key
+// rotation. The background rotation and cleaning processes keep the context's
+// values but not its cancellation, so they run until Stop is called.
+func (r *Rotator) StartContext(ctx context.Context) error {
+	if r.status == RotatorStatusStarted {
+		return ErrRotatorAlreadyRunning
+	}
+
+	r.controller.TurnOnContext(context.WithoutCancel(ctx))
+
+	if r.settings.AutoClearExpiredKeys {
+		interval := r.settings.KeyExpiration + time.Second
+		r.cleaner.Start(r.controller.Context(), interval)
+	}
+
+	resumed, err := r.resume(ctx)
+	if err == nil && !resumed {
+		err = r.RotateContext(ctx)
+	}
+
+	if err != nil {
+		r.cleaner.Stop()
+		r.controller.TurnOff()
+		return err
+	}
+
+	if watcher, ok := r.storage.(KeyWatcher); ok && r.settings.Follower {
+		changes, err := watcher.Watch(r.controller.Context())
+		if err != nil {
+			r.cleaner.Stop()
+			r.controller.TurnOff()
+			return err
+		}
+
+		go r.watch(r.controller.Context(), changes)
+	}
+
+	if requester, ok := r.lock.(RotationRequester); ok {
+		requests, err := requester.RotationRequests(r.controller.Context(), r.id)
+		if err != nil {
+			r.cleaner.Stop()
+			r.controller.TurnOff()
+			return err
+		}
+
+		go r.watch(r.controller.Context(), requests)
+	}
+
+	go r.run(r.controller.Context())
+
+	r.setStatus(RotatorStatusStarted)
+	r.onStartHooks.Run(r)
+
+	return nil
+}
+
+// StartContext works like Start, using the given context for the initial key
+// rotation. The background rotation and cleaning processes keep the context's
+// values but not its cancellation, so they run until Stop is called.
+func StartContext(ctx context.Context) error { return rotator.StartContext(ctx) }
 
 // Stop halts the key rotation process. If the Rotator is already inactive, it
 // immediately returns. Otherwise, it disposes the rotation controller, stops the
@@ -868,6 +1794,14 @@ func (r *Rotator) Stop() {
 	r.cleaner.Stop()
 	r.setStatus(RotatorStatusStopped)
 
+	if r.lock != nil {
+		r.lock.Release(context.Background(), r.id)
+
+		r.controller.Lock()
+		r.leader = false
+		r.controller.Unlock()
+	}
+
 	r.onStopHooks.Run(r)
 }
 
@@ -892,16 +1826,182 @@ func (r *Rotator) Stop() {
 // After calling Stop, the Rotator can be restarted with the Start method.
 func Stop() { rotator.Stop() }
 
-func (r *Rotator) run() error {
+// Keys returns every non-expired key in the Rotator's storage.
+// If the storage does not implement KeyLister, only the keys generated by
+// this Rotator are returned.
+func (r *Rotator) Keys(ctx context.Context) ([]*Key, error) {
+	if lister, ok := r.storage.(KeyLister); ok {
+		return lister.List(ctx, nil)
+	}
+
+	keys, err := r.publishedKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&KeyListOptions{}).apply(keys, r.now()), nil
+}
+
+// Keys returns every non-expired key in the Rotator's storage.
+// If the storage does not implement KeyLister, only the keys generated by
+// this Rotator are returned.
+func Keys(ctx context.Context) ([]*Key, error) { return rotator.Keys(ctx) }
+
+// publishedKey is a key published by the Rotator, tracked for the storages
+// that do not implement KeyLister.
+type publishedKey struct {
+	id      string
+	expires time.Time
+}
+
+// publish tracks the given keys, if the storage does not implement KeyLister,
+// and forgets the tracked keys that have expired by the given time.
+// The controller must be locked.
+func (r *Rotator) publish(now time.Time, keys ...*Key) {
+	if _, ok := r.storage.(KeyLister); ok {
+		r.published = nil
+		return
+	}
+
+	r.published = slices.DeleteFunc(r.published, func(key publishedKey) bool {
+		return key.expires.Before(now)
+	})
+
+	for _, key := range keys {
+		tracked := slices.ContainsFunc(r.published, func(published publishedKey) bool {
+			return published.id == key.ID
+		})
+		if !tracked {
+			r.published = append(r.published, publishedKey{id: key.ID, expires: key.Expires})
+		}
+	}
+}
+
+// publishedKeys returns the keys published by the rotator that are still
+// present in its storage, forgetting the ones that have since been removed.
+func (r *Rotator) publishedKeys(ctx context.Context) ([]*Key, error) {
+	r.controller.Lock()
+	defer r.controller.Unlock()
+
+	keys := make([]*Key, 0, len(r.published))
+	published := r.published[:0]
+	for _, tracked := range r.published {
+		key, err := r.storage.Get(ctx, tracked.id)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+		published = append(published, tracked)
+	}
+
+	r.published = published
+	return keys, nil
+}
+
+// newCleaner returns a KeyCleaner for the given storage that shares the
+// Rotator's clock and emits a KeysCleaned event after removing keys.
+func (r *Rotator) newCleaner(storage KeyStorage) KeyCleaner {
+	cleaner := &keyCleaner{storage: storage, clock: r.clock()}
+	cleaner.onCleaned = func(count int) {
+		r.subscribers.emit(KeysCleaned{Count: count})
+	}
+
+	return cleaner
+}
+
+// clock returns the clock set in the Rotator's settings, or the system clock.
+func (r *Rotator) clock() Clock {
+	if r.settings == nil || r.settings.Clock == nil {
+		return NewClock()
+	}
+
+	return r.settings.Clock
+}
+
+func (r *Rotator) now() time.Time {
+	return r.clock().Now()
+}
+
+// shareClock hands the clock set in the Rotator's settings to the given
+// components of this package. Custom components keep their own notion of time.
+func (r *Rotator) shareClock(components ...any) {
+	if r.settings == nil || r.settings.Clock == nil {
+		return
+	}
+
+	for _, component := range components {
+		if setter, ok := component.(clockSetter); ok {
+			setter.setClock(r.clock())
+		}
+	}
+}
+
+// nextRotation returns when the running rotator is expected to rotate again,
+// or the zero time if it is stopped.
+func (r *Rotator) nextRotation() time.Time {
+	r.controller.Lock()
+	defer r.controller.Unlock()
+
+	if r.status != RotatorStatusStarted {
+		return time.Time{}
+	}
+
+	return r.scheduledAt
+}
+
+const (
+	// rotationRetryDelay is the delay before retrying a failed scheduled
+	// rotation. It doubles after each further failure, up to
+	// maxRotationRetryDelay.
+	rotationRetryDelay = time.Second
+
+	// maxRotationRetryDelay is the longest delay between retries of a failed
+	// scheduled rotation.
+	maxRotationRetryDelay = time.Minute
+)
+
+// run rotates the keys on schedule until the context is done. Failed
+// rotations, which are reported as RotationFailed events, are retried with an
+// exponential backoff.
+func (r *Rotator) run(ctx context.Context) {
+	delay := rotationRetryDelay
 	for {
-		if r.controller.Disposed() {
-			return nil
+		r.controller.Lock()
+		next := r.scheduledAt
+		r.controller.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-r.clock().After(next.Sub(r.now())):
+			// A manual rotation may have pushed the schedule back meanwhile.
+			r.controller.Lock()
+			next = r.scheduledAt
+			r.controller.Unlock()
+
+			if now.Before(next) {
+				continue
+			}
 		}
 
-		time.Sleep(r.settings.RotationInterval)
+		if err := r.RotateContext(ctx); err != nil {
+			select {
+			case <-ctx.Done():
+				return
 
-		if err := r.Rotate(); err != nil {
-			return err
+			case <-r.clock().After(delay):
+			}
+
+			delay = min(2*delay, maxRotationRetryDelay)
+			continue
 		}
+
+		delay = rotationRetryDelay
 	}
 }
@@ -0,0 +1,120 @@
+// Package krottest provides utilities for testing code built on krot.
+package krottest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a krot.Clock whose time only moves when Advance or Set is called,
+// allowing rotation and expiration to be tested deterministically.
+//
+//	clock := krottest.NewClock(time.Now())
+//	settings.Clock = clock
+//	rotator.Start()
+//
+//	clock.BlockUntil(1)              // the rotator is waiting for the next rotation
+//	clock.Advance(settings.RotationInterval)
+type Clock struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*clockWaiter
+}
+
+type clockWaiter struct {
+	deadline time.Time
+	channel  chan time.Time
+}
+
+// NewClock returns a Clock set to the given time.
+func NewClock(now time.Time) *Clock {
+	clock := &Clock{now: now}
+	clock.cond = sync.NewCond(&clock.mutex)
+
+	return clock
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// After returns a channel that receives the clock's time once it has been
+// advanced by at least the given duration.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	waiter := &clockWaiter{
+		deadline: c.now.Add(d),
+		channel:  make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		waiter.channel <- c.now
+		return waiter.channel
+	}
+
+	c.waiters = append(c.waiters, waiter)
+	c.cond.Broadcast()
+
+	return waiter.channel
+}
+
+// Advance moves the clock forward by the given duration, firing every waiter
+// whose deadline has been reached.
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to the given time, firing every waiter whose deadline
+// has been reached.
+func (c *Clock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.set(now)
+}
+
+func (c *Clock) set(now time.Time) {
+	c.now = now
+
+	waiters := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(now) {
+			waiters = append(waiters, waiter)
+			continue
+		}
+
+		waiter.channel <- now
+	}
+
+	c.waiters = waiters
+	c.cond.Broadcast()
+}
+
+// Waiters returns the number of pending After calls.
+func (c *Clock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until at least n After calls are pending. It is used to
+// make sure background goroutines are waiting on the clock before advancing it.
+func (c *Clock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
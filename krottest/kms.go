@@ -0,0 +1,138 @@
+package krottest
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"sync"
+)
+
+// ErrKMSKeyNotFound is returned by KMS.UnwrapKey when the wrapped key was not
+// wrapped by the KMS.
+var ErrKMSKeyNotFound = errors.New("krottest: KMS key not found")
+
+// KMS is a krot.KeyWrapper standing in for a key management service: it keeps
+// its key encryption keys (KEKs) in memory, can rotate them while still
+// unwrapping keys wrapped by the previous ones, counts its calls and can be
+// made to fail.
+//
+//	kms := krottest.NewKMS()
+//	storage := krot.NewEncryptedKeyStorage(krot.NewKeyStorage(), kms)
+type KMS struct {
+	mutex    sync.Mutex
+	versions []cipher.AEAD
+	wraps    int
+	unwraps  int
+	err      error
+}
+
+// NewKMS returns a KMS holding a single random KEK.
+func NewKMS() *KMS {
+	kms := &KMS{}
+	kms.RotateKEK()
+
+	return kms
+}
+
+// RotateKEK adds a new random KEK, used by the following calls to WrapKey.
+// Keys wrapped by the previous KEKs can still be unwrapped.
+func (k *KMS) RotateKEK() {
+	kek := make([]byte, 32)
+	if _, err := rand.Read(kek); err != nil {
+		panic(err)
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		panic(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.versions = append(k.versions, aead)
+}
+
+// Fail makes the following calls to WrapKey and UnwrapKey return the given
+// error, or succeed again if it is nil.
+func (k *KMS) Fail(err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.err = err
+}
+
+// Calls returns the number of successful calls to WrapKey and UnwrapKey.
+func (k *KMS) Calls() (wraps, unwraps int) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	return k.wraps, k.unwraps
+}
+
+// WrapKey encrypts the key under the latest KEK. The wrapped key starts with
+// the version of the KEK.
+func (k *KMS) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.err != nil {
+		return nil, k.err
+	}
+
+	version := len(k.versions) - 1
+	aead := k.versions[version]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	wrapped := append([]byte{byte(version)}, nonce...)
+	k.wraps++
+
+	return aead.Seal(wrapped, nonce, key, nil), nil
+}
+
+// UnwrapKey decrypts a key wrapped by WrapKey.
+func (k *KMS) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.err != nil {
+		return nil, k.err
+	}
+
+	if len(wrapped) == 0 || int(wrapped[0]) >= len(k.versions) {
+		return nil, ErrKMSKeyNotFound
+	}
+
+	aead := k.versions[wrapped[0]]
+	wrapped = wrapped[1:]
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrKMSKeyNotFound
+	}
+
+	key, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrKMSKeyNotFound
+	}
+
+	k.unwraps++
+	return key, nil
+}
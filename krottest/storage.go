@@ -0,0 +1,386 @@
+package krottest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhaori96/krot"
+)
+
+// StorageFactory returns a new, empty krot.KeyStorage for a conformance test.
+type StorageFactory func(t *testing.T) krot.KeyStorage
+
+// conformanceWorkers is the number of goroutines of the concurrency tests.
+const conformanceWorkers = 8
+
+// RunStorageConformance runs the tests pinning down the contract of a
+// krot.KeyStorage against the storages returned by the factory, one per test,
+// so that custom storages can prove they behave like the ones of krot:
+//
+//   - Get returns an error wrapping krot.ErrKeyNotFound, and no key, for
+//     unknown IDs.
+//   - Get returns expired keys until ClearDeprecated removes them.
+//   - Add stores every field of the keys, replaces keys with the same ID, and
+//     skips nil keys, keys without an ID and keys whose value is "".
+//   - Get returns values of the type they were added with, such as []byte
+//     secrets and *krot.KeyPair, which storages keeping keys out of memory
+//     encode with krot.MarshalKeyValue or Key.MarshalJSON.
+//   - Delete ignores unknown IDs.
+//   - ClearDeprecated removes the expired keys only; Erase removes them all.
+//   - PurgeDeprecated, if the storage implements krot.KeyPurger, works like
+//     ClearDeprecated and returns the number of removed keys.
+//   - List, if the storage implements krot.KeyLister, follows
+//     krot.KeyListOptions.
+//   - Every method is safe for concurrent use.
+//
+// Keys are otherwise given string values, and timestamps with a precision of a
+// second.
+//
+//	func TestRedisKeyStorage(t *testing.T) {
+//	    krottest.RunStorageConformance(t, func(t *testing.T) krot.KeyStorage {
+//	        return NewRedisKeyStorage(newRedisClient(t))
+//	    })
+//	}
+func RunStorageConformance(t *testing.T, factory StorageFactory) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("Get should return ErrKeyNotFound for unknown keys", func(t *testing.T) {
+		storage := factory(t)
+
+		key, err := storage.Get(ctx, "unknown")
+		if !errors.Is(err, krot.ErrKeyNotFound) {
+			t.Errorf("Get(unknown) error = %v, want ErrKeyNotFound", err)
+		}
+
+		if key != nil {
+			t.Errorf("Get(unknown) = %+v, want nil", key)
+		}
+	})
+
+	t.Run("Add should store every field", func(t *testing.T) {
+		storage := factory(t)
+
+		want := &krot.Key{
+			ID:          "key",
+			Value:       "value",
+			Expires:     now.Add(time.Hour),
+			State:       krot.KeyStateRetiring,
+			CreatedAt:   now.Add(-2 * time.Hour),
+			ActivatedAt: now.Add(-time.Hour),
+			RetiredAt:   now,
+		}
+		mustAdd(t, storage, want)
+
+		assertKey(t, mustGet(t, storage, "key"), want)
+	})
+
+	t.Run("Add should replace keys with the same ID", func(t *testing.T) {
+		storage := factory(t)
+
+		mustAdd(t, storage, &krot.Key{ID: "key", Value: "old", Expires: now.Add(time.Hour)})
+
+		want := &krot.Key{ID: "key", Value: "new", Expires: now.Add(2 * time.Hour), State: krot.KeyStateRevoked, RevokedAt: now}
+		mustAdd(t, storage, want)
+
+		assertKey(t, mustGet(t, storage, "key"), want)
+	})
+
+	t.Run("Add should skip invalid keys", func(t *testing.T) {
+		storage := factory(t)
+
+		mustAdd(t, storage,
+			nil,
+			&krot.Key{Value: "value", Expires: now.Add(time.Hour)},
+			&krot.Key{ID: "empty", Value: "", Expires: now.Add(time.Hour)},
+			&krot.Key{ID: "valid", Value: "value", Expires: now.Add(time.Hour)},
+		)
+
+		assertNotFound(t, storage, "empty")
+		mustGet(t, storage, "valid")
+	})
+
+	t.Run("Add should preserve value types", func(t *testing.T) {
+		storage := factory(t)
+
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey() error = %v", err)
+		}
+
+		values := map[string]any{
+			"bytes": []byte{0, 1, 2, 254, 255},
+			"pair":  &krot.KeyPair{Private: privateKey, Public: publicKey, Algorithm: krot.AlgorithmEdDSA},
+		}
+
+		for id, value := range values {
+			mustAdd(t, storage, &krot.Key{ID: id, Value: value, Expires: now.Add(time.Hour)})
+		}
+
+		for id, want := range values {
+			if got := mustGet(t, storage, id).Value; !reflect.DeepEqual(got, want) {
+				t.Errorf("Get(%s).Value = %#v, want %#v", id, got, want)
+			}
+		}
+	})
+
+	t.Run("Get should return expired keys", func(t *testing.T) {
+		storage := factory(t)
+
+		want := &krot.Key{ID: "expired", Value: "value", Expires: now.Add(-time.Hour)}
+		mustAdd(t, storage, want)
+
+		assertKey(t, mustGet(t, storage, "expired"), want)
+	})
+
+	t.Run("Delete should remove keys", func(t *testing.T) {
+		storage := factory(t)
+
+		mustAdd(t, storage,
+			&krot.Key{ID: "1", Value: "1", Expires: now.Add(time.Hour)},
+			&krot.Key{ID: "2", Value: "2", Expires: now.Add(time.Hour)},
+			&krot.Key{ID: "3", Value: "3", Expires: now.Add(time.Hour)},
+		)
+
+		if err := storage.Delete(ctx, "1", "2"); err != nil {
+			t.Fatalf("Delete(1, 2) error = %v", err)
+		}
+
+		assertNotFound(t, storage, "1")
+		assertNotFound(t, storage, "2")
+		mustGet(t, storage, "3")
+	})
+
+	t.Run("Delete should ignore unknown keys", func(t *testing.T) {
+		storage := factory(t)
+
+		if err := storage.Delete(ctx, "unknown"); err != nil {
+			t.Errorf("Delete(unknown) error = %v, want nil", err)
+		}
+
+		mustAdd(t, storage, &krot.Key{ID: "key", Value: "value", Expires: now.Add(time.Hour)})
+		for i := 0; i < 2; i++ {
+			if err := storage.Delete(ctx, "key"); err != nil {
+				t.Errorf("Delete(key) #%d error = %v, want nil", i+1, err)
+			}
+		}
+	})
+
+	t.Run("ClearDeprecated should remove expired keys only", func(t *testing.T) {
+		storage := factory(t)
+
+		mustAdd(t, storage,
+			&krot.Key{ID: "expired", Value: "value", Expires: now.Add(-time.Hour)},
+			&krot.Key{ID: "valid", Value: "value", Expires: now.Add(time.Hour)},
+		)
+
+		if err := storage.ClearDeprecated(ctx); err != nil {
+			t.Fatalf("ClearDeprecated() error = %v", err)
+		}
+
+		assertNotFound(t, storage, "expired")
+		mustGet(t, storage, "valid")
+	})
+
+	t.Run("PurgeDeprecated should count the removed keys", func(t *testing.T) {
+		storage := factory(t)
+
+		purger, ok := storage.(krot.KeyPurger)
+		if !ok {
+			t.Skip("storage does not implement KeyPurger")
+		}
+
+		mustAdd(t, storage,
+			&krot.Key{ID: "expired-1", Value: "value", Expires: now.Add(-time.Hour)},
+			&krot.Key{ID: "expired-2", Value: "value", Expires: now.Add(-time.Minute)},
+			&krot.Key{ID: "valid", Value: "value", Expires: now.Add(time.Hour)},
+		)
+
+		count, err := purger.PurgeDeprecated(ctx)
+		if err != nil {
+			t.Fatalf("PurgeDeprecated() error = %v", err)
+		}
+
+		if count != 2 {
+			t.Errorf("PurgeDeprecated() = %d, want 2", count)
+		}
+
+		assertNotFound(t, storage, "expired-1")
+		assertNotFound(t, storage, "expired-2")
+		mustGet(t, storage, "valid")
+	})
+
+	t.Run("Erase should remove every key", func(t *testing.T) {
+		storage := factory(t)
+
+		mustAdd(t, storage,
+			&krot.Key{ID: "expired", Value: "value", Expires: now.Add(-time.Hour)},
+			&krot.Key{ID: "valid", Value: "value", Expires: now.Add(time.Hour)},
+		)
+
+		if err := storage.Erase(ctx); err != nil {
+			t.Fatalf("Erase() error = %v", err)
+		}
+
+		assertNotFound(t, storage, "expired")
+		assertNotFound(t, storage, "valid")
+	})
+
+	t.Run("List should follow the options", func(t *testing.T) {
+		storage := factory(t)
+
+		lister, ok := storage.(krot.KeyLister)
+		if !ok {
+			t.Skip("storage does not implement KeyLister")
+		}
+
+		mustAdd(t, storage,
+			&krot.Key{ID: "3", Value: "3", Expires: now.Add(2 * time.Hour)},
+			&krot.Key{ID: "2", Value: "2", Expires: now.Add(time.Hour), State: krot.KeyStatePending},
+			&krot.Key{ID: "1", Value: "1", Expires: now.Add(time.Hour)},
+			&krot.Key{ID: "0", Value: "0", Expires: now.Add(-time.Hour)},
+		)
+
+		tests := []struct {
+			options *krot.KeyListOptions
+			want    []string
+		}{
+			{nil, []string{"1", "2", "3"}},
+			{&krot.KeyListOptions{IncludeExpired: true}, []string{"0", "1", "2", "3"}},
+			{&krot.KeyListOptions{Offset: 1, Limit: 1}, []string{"2"}},
+			{&krot.KeyListOptions{Offset: 3}, []string{}},
+			{&krot.KeyListOptions{ExpiresAfter: now.Add(time.Hour)}, []string{"3"}},
+			{&krot.KeyListOptions{ExpiresBefore: now.Add(2 * time.Hour)}, []string{"1", "2"}},
+			{&krot.KeyListOptions{States: []krot.KeyState{krot.KeyStatePending}}, []string{"2"}},
+		}
+
+		for _, test := range tests {
+			keys, err := lister.List(ctx, test.options)
+			if err != nil {
+				t.Errorf("List(%+v) error = %v", test.options, err)
+				continue
+			}
+
+			if got := keyIDs(keys); fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("List(%+v) = %v, want %v", test.options, got, test.want)
+			}
+		}
+	})
+
+	t.Run("Methods should be safe for concurrent use", func(t *testing.T) {
+		storage := factory(t)
+
+		var wg sync.WaitGroup
+		for worker := 0; worker < conformanceWorkers; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+
+				for i := 0; i < 10; i++ {
+					id := fmt.Sprintf("%d:%d", worker, i)
+					if err := storage.Add(ctx, &krot.Key{ID: id, Value: id, Expires: now.Add(time.Hour)}); err != nil {
+						t.Errorf("Add(%s) error = %v", id, err)
+						return
+					}
+
+					key, err := storage.Get(ctx, id)
+					if err != nil || key.Value != id {
+						t.Errorf("Get(%s) = %+v, %v, want the added key", id, key, err)
+						return
+					}
+
+					if i%2 == 0 {
+						continue
+					}
+
+					if err := storage.Delete(ctx, id); err != nil {
+						t.Errorf("Delete(%s) error = %v", id, err)
+						return
+					}
+				}
+			}(worker)
+		}
+		wg.Wait()
+
+		for worker := 0; worker < conformanceWorkers; worker++ {
+			for i := 0; i < 10; i++ {
+				id := fmt.Sprintf("%d:%d", worker, i)
+				if i%2 == 0 {
+					mustGet(t, storage, id)
+				} else {
+					assertNotFound(t, storage, id)
+				}
+			}
+		}
+	})
+}
+
+func mustAdd(t *testing.T, storage krot.KeyStorage, keys ...*krot.Key) {
+	t.Helper()
+
+	if err := storage.Add(context.Background(), keys...); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+}
+
+func mustGet(t *testing.T, storage krot.KeyStorage, id string) *krot.Key {
+	t.Helper()
+
+	key, err := storage.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", id, err)
+	}
+
+	return key
+}
+
+func assertNotFound(t *testing.T, storage krot.KeyStorage, id string) {
+	t.Helper()
+
+	if _, err := storage.Get(context.Background(), id); !errors.Is(err, krot.ErrKeyNotFound) {
+		t.Errorf("Get(%s) error = %v, want ErrKeyNotFound", id, err)
+	}
+}
+
+func assertKey(t *testing.T, got, want *krot.Key) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Value != want.Value || got.State != want.State {
+		t.Errorf("got key %s = %v (%s), want %s = %v (%s)", got.ID, got.Value, got.State, want.ID, want.Value, want.State)
+	}
+
+	times := []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"Expires", got.Expires, want.Expires},
+		{"CreatedAt", got.CreatedAt, want.CreatedAt},
+		{"ActivatedAt", got.ActivatedAt, want.ActivatedAt},
+		{"RetiredAt", got.RetiredAt, want.RetiredAt},
+		{"RevokedAt", got.RevokedAt, want.RevokedAt},
+	}
+
+	for _, field := range times {
+		if !field.got.Equal(field.want) {
+			t.Errorf("got key %s %s = %s, want %s", got.ID, field.name, field.got, field.want)
+		}
+	}
+}
+
+func keyIDs(keys []*krot.Key) []string {
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ID
+	}
+
+	return ids
+}
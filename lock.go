@@ -0,0 +1,352 @@
+package krot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RotationLock coordinates rotators running on several replicas that share a
+// KeyStorage. Before each rotation, every rotator tries to acquire the lock's
+// lease: the one holding it, the leader, generates the keys, while the others
+// become followers and load the current key set from the shared storage.
+type RotationLock interface {
+	// Acquire acquires the lease for the given owner, or renews it if the owner
+	// already holds it, for the given duration. It returns false, without an
+	// error, if the lease is held by another owner.
+	//
+	//     leader, err := lock.Acquire(ctx, rotator.ID(), time.Hour)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	Acquire(context context.Context, owner string, ttl time.Duration) (bool, error)
+
+	// Release gives up the lease if it is held by the given owner, so that
+	// another owner can acquire it without waiting for it to expire.
+	//
+	//     err := lock.Release(ctx, rotator.ID())
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	Release(context context.Context, owner string) error
+}
+
+// RotationRequester is an optional interface for rotation locks through which
+// the rotators that do not hold the lease can ask the leader to rotate ahead of
+// schedule, e.g. once they exhaust the MaxKeyUses of every active key.
+type RotationRequester interface {
+	// RequestRotation asks the owner of the lease to rotate. It does nothing if
+	// the lease is not held, as the next rotator to rotate acquires it.
+	//
+	//     err := lock.(krot.RotationRequester).RequestRotation(ctx)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	RequestRotation(context context.Context) error
+
+	// RotationRequests returns a channel that receives a value whenever a
+	// rotation is requested while the given owner holds the lease. Requests may
+	// be coalesced. The channel is closed once the context is done.
+	//
+	//     requests, err := lock.(krot.RotationRequester).RotationRequests(ctx, rotator.ID())
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	RotationRequests(context context.Context, owner string) (<-chan struct{}, error)
+}
+
+// rotationLease is the state of a RotationLock.
+type rotationLease struct {
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+func (l *rotationLease) acquire(owner string, ttl time.Duration, now time.Time) bool {
+	if l.Owner != "" && l.Owner != owner && now.Before(l.Expires) {
+		return false
+	}
+
+	l.Owner = owner
+	l.Expires = now.Add(ttl)
+
+	return true
+}
+
+func (l *rotationLease) release(owner string) bool {
+	if l.Owner != owner {
+		return false
+	}
+
+	*l = rotationLease{}
+	return true
+}
+
+type inMemoryRotationLock struct {
+	mutex    sync.Mutex
+	lease    rotationLease
+	clock    Clock
+	requests map[chan struct{}]string
+}
+
+// NewRotationLock returns an in-memory RotationLock, coordinating the rotators
+// of a single process that share it. It is mostly useful for testing.
+// It implements RotationRequester.
+func NewRotationLock() RotationLock {
+	return NewRotationLockWithClock(NewClock())
+}
+
+// NewRotationLockWithClock returns an in-memory RotationLock whose leases
+// expire according to the given clock.
+func NewRotationLockWithClock(clock Clock) RotationLock {
+	return &inMemoryRotationLock{clock: clock, requests: make(map[chan struct{}]string)}
+}
+
+func (l *inMemoryRotationLock) setClock(clock Clock) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.clock = clock
+}
+
+func (l *inMemoryRotationLock) Acquire(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.lease.acquire(owner, ttl, l.clock.Now()), nil
+}
+
+func (l *inMemoryRotationLock) Release(ctx context.Context, owner string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lease.release(owner)
+	return nil
+}
+
+func (l *inMemoryRotationLock) RequestRotation(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.lease.Owner == "" || !l.clock.Now().Before(l.lease.Expires) {
+		return nil
+	}
+
+	for requests, owner := range l.requests {
+		if owner != l.lease.Owner {
+			continue
+		}
+
+		select {
+		case requests <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (l *inMemoryRotationLock) RotationRequests(ctx context.Context, owner string) (<-chan struct{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	requests := make(chan struct{}, 1)
+
+	l.mutex.Lock()
+	l.requests[requests] = owner
+	l.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		l.mutex.Lock()
+		delete(l.requests, requests)
+		close(requests)
+		l.mutex.Unlock()
+	}()
+
+	return requests, nil
+}
+
+type fileRotationLock struct {
+	path  string
+	mutex sync.Mutex
+	clock Clock
+}
+
+// NewFileRotationLock returns a RotationLock that keeps its lease in the file at
+// the given path, coordinating the rotators of the processes of a single host.
+// The file is created with 0600 permissions and guarded by an advisory lock.
+// Locking is supported on unix and Windows; on other platforms, Acquire and
+// Release fail with errors.ErrUnsupported.
+func NewFileRotationLock(path string) RotationLock {
+	return NewFileRotationLockWithClock(path, NewClock())
+}
+
+// NewFileRotationLockWithClock returns a file RotationLock whose leases expire
+// according to the given clock.
+func NewFileRotationLockWithClock(path string, clock Clock) RotationLock {
+	return &fileRotationLock{path: path, clock: clock}
+}
+
+func (l *fileRotationLock) setClock(clock Clock) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.clock = clock
+}
+
+func (l *fileRotationLock) now() time.Time {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.clock.Now()
+}
+
+func (l *fileRotationLock) Acquire(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := l.now()
+
+	acquired := false
+	err := l.update(ctx, func(lease *rotationLease) bool {
+		acquired = lease.acquire(owner, ttl, now)
+		return acquired
+	})
+
+	return acquired, err
+}
+
+func (l *fileRotationLock) Release(ctx context.Context, owner string) error {
+	return l.update(ctx, func(lease *rotationLease) bool {
+		return lease.release(owner)
+	})
+}
+
+// update locks the lease file and applies the given function to the lease,
+// writing it back if the function reports a change.
+func (l *fileRotationLock) update(ctx context.Context, apply func(lease *rotationLease) bool) error {
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := lockFile(ctx, file); err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	lease := &rotationLease{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, lease); err != nil {
+			return fmt.Errorf("rotation lock %s is corrupted: %w", l.path, err)
+		}
+	}
+
+	if !apply(lease) {
+		return nil
+	}
+
+	data, err = json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// lockFileRetryInterval is the delay between attempts to lock a file already
+// locked by another process.
+const lockFileRetryInterval = 10 * time.Millisecond
+
+// lockFile places an exclusive lock on the file, with flock on unix and
+// LockFileEx on Windows, waiting for other processes to release theirs until
+// the context is done.
+func lockFile(ctx context.Context, file *os.File) error {
+	for {
+		err := tryLockFile(file)
+		if !errors.Is(err, errFileLocked) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-time.After(lockFileRetryInterval):
+		}
+	}
+}
+
+// writeFileAtomic replaces the file at the given path with the given data,
+// writing it to a temporary file of the same directory first, so that readers
+// either see the previous content or the new one, even after a crash.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	// The rename itself is only durable once the directory is synced.
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
@@ -0,0 +1,302 @@
+package krot
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RotationSchedule determines when rotations happen.
+// It is set in RotatorSettings.RotationSchedule; without it, the Rotator
+// rotates every RotationInterval counting from the previous rotation.
+type RotationSchedule interface {
+	// Next returns the time of the first rotation strictly after the given time,
+	// or the zero time if there is none.
+	Next(after time.Time) time.Time
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// NewIntervalSchedule returns a RotationSchedule that rotates every interval,
+// counting from the previous rotation. Restarts shift the rotation times.
+// Non-positive intervals have no next rotation, so that the Rotator falls back
+// to its RotationInterval.
+func NewIntervalSchedule(interval time.Duration) RotationSchedule {
+	return &intervalSchedule{interval: interval}
+}
+
+func (s *intervalSchedule) Next(after time.Time) time.Time {
+	if s.interval <= 0 {
+		return time.Time{}
+	}
+
+	return after.Add(s.interval)
+}
+
+type alignedSchedule struct {
+	interval time.Duration
+	offset   time.Duration
+}
+
+// NewAlignedSchedule returns a RotationSchedule that rotates at fixed points of
+// the calendar: every interval, shifted by offset, counting from midnight UTC.
+// Intervals should divide a day evenly, so that rotation times are the same
+// every day and do not depend on restarts.
+//
+//	krot.NewAlignedSchedule(24*time.Hour, 3*time.Hour) // every day at 03:00 UTC
+//	krot.NewAlignedSchedule(6*time.Hour, 0)            // at 00:00, 06:00, 12:00 and 18:00 UTC
+func NewAlignedSchedule(interval, offset time.Duration) RotationSchedule {
+	return &alignedSchedule{interval: interval, offset: offset}
+}
+
+func (s *alignedSchedule) Next(after time.Time) time.Time {
+	if s.interval <= 0 {
+		return time.Time{}
+	}
+
+	next := after.UTC().Add(-s.offset).Truncate(s.interval).Add(s.offset)
+	for !next.After(after) {
+		next = next.Add(s.interval)
+	}
+
+	return next.In(after.Location())
+}
+
+// cronSearchLimit bounds the search for the next time matching a cron expression.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinutes  = cronField{name: "minute", min: 0, max: 59}
+	cronHours    = cronField{name: "hour", min: 0, max: 23}
+	cronDays     = cronField{name: "day of month", min: 1, max: 31}
+	cronMonths   = cronField{name: "month", min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	cronWeekdays = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays uint64
+
+	// anyDay and anyWeekday record unrestricted day fields: when both day
+	// fields are restricted, a time matches if either of them does.
+	anyDay, anyWeekday bool
+
+	location *time.Location
+}
+
+// NewCronSchedule returns a RotationSchedule that rotates at the times matching
+// a standard five-field cron expression (minute, hour, day of month, month and
+// day of week), evaluated in the given location, or UTC if it is nil.
+//
+// Fields accept "*", values, ranges ("1-5"), steps ("*/15", "0-30/10"), lists
+// ("1,15") and, for months and days of the week, names ("jan", "mon").
+// The descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and
+// @hourly are supported as well.
+//
+//	schedule, err := krot.NewCronSchedule("0 3 * * *", nil) // every day at 03:00 UTC
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func NewCronSchedule(expression string, location *time.Location) (RotationSchedule, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	spec := strings.TrimSpace(expression)
+	if descriptor, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(
+			"%w: cron expression %q must have 5 fields (got %d)",
+			ErrInvalidRotationSchedule,
+			expression,
+			len(fields),
+		)
+	}
+
+	schedule := &cronSchedule{
+		location:   location,
+		anyDay:     fields[2] == "*" || fields[2] == "?",
+		anyWeekday: fields[4] == "*" || fields[4] == "?",
+	}
+
+	parsers := []struct {
+		field cronField
+		bits  *uint64
+	}{
+		{cronMinutes, &schedule.minutes},
+		{cronHours, &schedule.hours},
+		{cronDays, &schedule.days},
+		{cronMonths, &schedule.months},
+		{cronWeekdays, &schedule.weekdays},
+	}
+
+	for i, parser := range parsers {
+		bits, err := parser.field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: cron expression %q: %w", ErrInvalidRotationSchedule, expression, err)
+		}
+
+		*parser.bits = bits
+	}
+
+	// Sunday can be written as both 0 and 7.
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: cron expression %q never matches", ErrInvalidRotationSchedule, expression)
+	}
+
+	return schedule, nil
+}
+
+func (f cronField) parse(expression string) (uint64, error) {
+	var result uint64
+	for _, part := range strings.Split(expression, ",") {
+		bits, err := f.parseRange(part)
+		if err != nil {
+			return 0, err
+		}
+
+		result |= bits
+	}
+
+	return result, nil
+}
+
+func (f cronField) parseRange(expression string) (uint64, error) {
+	rangeExpression, stepExpression, hasStep := strings.Cut(expression, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepExpression)
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("invalid %s step %q", f.name, stepExpression)
+		}
+	}
+
+	low, high := f.min, f.max
+	switch {
+	case rangeExpression == "*" || rangeExpression == "?":
+
+	case strings.Contains(rangeExpression, "-"):
+		lowExpression, highExpression, _ := strings.Cut(rangeExpression, "-")
+
+		var err error
+		if low, err = f.parseValue(lowExpression); err != nil {
+			return 0, err
+		}
+
+		if high, err = f.parseValue(highExpression); err != nil {
+			return 0, err
+		}
+
+		if low > high {
+			return 0, fmt.Errorf("invalid %s range %q", f.name, rangeExpression)
+		}
+
+	default:
+		value, err := f.parseValue(rangeExpression)
+		if err != nil {
+			return 0, err
+		}
+
+		low = value
+		if !hasStep {
+			high = value
+		}
+	}
+
+	var result uint64
+	for value := low; value <= high; value += step {
+		result |= 1 << value
+	}
+
+	return result, nil
+}
+
+func (f cronField) parseValue(expression string) (int, error) {
+	if value, ok := f.names[strings.ToLower(expression)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(expression)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %s %q", f.name, expression)
+	}
+
+	return value, nil
+}
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			// Jump straight to the next matching minute of the hour, if any.
+			remaining := s.minutes >> uint(t.Minute())
+			if remaining == 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(remaining)) * time.Minute)
+			}
+			continue
+		}
+
+		return t.In(after.Location())
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+
+	return day || weekday
+}
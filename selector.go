@@ -0,0 +1,512 @@
+package krot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	cryptorand "crypto/rand"
+	mathrand "math/rand"
+)
+
+// KeySelector selects the key handed out by Rotator.GetKey among the active
+// keys of a Rotator, which consults it under its lock. The built-in selectors
+// implement the KeyProvidingModes; custom ones, such as weighted or
+// newest-first selection, are set with Rotator.SetKeySelector.
+//
+// The built-in selectors are safe for concurrent use, so they can be used on
+// their own as well.
+type KeySelector interface {
+	// Set replaces the IDs to select from with the IDs of the active keys.
+	// It is called whenever the active keys change, e.g. after a rotation.
+	//
+	//     selector.Set("key-1", "key-2", "key-3")
+	Set(ids ...string)
+
+	// Select returns one of the IDs. If there are none, it returns an
+	// ErrNoKeysGenerated.
+	//
+	//     id, err := selector.Select()
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	Select() (string, error)
+}
+
+// keySelectorCursor is implemented by the selectors going through the IDs in
+// order, whose position is saved in the RotatorSnapshot.
+type keySelectorCursor interface {
+	cursor() int
+	seek(cursor int)
+}
+
+// RandomSource is the source of randomness of the built-in KeySelectors.
+// It must be safe for concurrent use.
+type RandomSource interface {
+	// Intn returns a uniformly distributed number in [0, n). It panics if n
+	// is not positive.
+	Intn(n int) int
+}
+
+var defaultRandomSource = NewCryptoRandomSource()
+
+type cryptoRandomSource struct{}
+
+// NewCryptoRandomSource returns a RandomSource reading from crypto/rand, so
+// that the selected keys cannot be predicted. It is the default source of the
+// built-in KeySelectors.
+func NewCryptoRandomSource() RandomSource {
+	return cryptoRandomSource{}
+}
+
+func (cryptoRandomSource) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	// Rejecting the values above the largest multiple of n avoids modulo bias.
+	limit := math.MaxUint64 - math.MaxUint64%uint64(n)
+
+	var buffer [8]byte
+	for {
+		if _, err := cryptorand.Read(buffer[:]); err != nil {
+			panic(err)
+		}
+
+		if value := binary.LittleEndian.Uint64(buffer[:]); value < limit {
+			return int(value % uint64(n))
+		}
+	}
+}
+
+type seededRandomSource struct {
+	mu     sync.Mutex
+	random *mathrand.Rand
+}
+
+// NewSeededRandomSource returns a RandomSource of math/rand seeded with the
+// given seed, whose sequence is reproducible, e.g. in tests. It is faster than
+// the crypto source, but its output can be predicted.
+func NewSeededRandomSource(seed int64) RandomSource {
+	return &seededRandomSource{random: mathrand.New(mathrand.NewSource(seed))}
+}
+
+func (s *seededRandomSource) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.random.Intn(n)
+}
+
+// NewKeySelector returns the built-in KeySelector of the given mode, using the
+// default RandomSource. If the mode is unknown, it returns an
+// ErrInvalidKeyProvidingMode.
+func NewKeySelector(mode KeyProvidingMode) (KeySelector, error) {
+	return NewKeySelectorWithRandom(mode, defaultRandomSource)
+}
+
+// NewKeySelectorWithRandom returns the built-in KeySelector of the given mode,
+// using the given RandomSource:
+//
+//	selector, err := krot.NewKeySelectorWithRandom(krot.RandomKeyProvidingMode, krot.NewSeededRandomSource(42))
+//
+// If the mode is unknown or the source is nil, it returns an error.
+func NewKeySelectorWithRandom(mode KeyProvidingMode, random RandomSource) (KeySelector, error) {
+	if random == nil {
+		return nil, fmt.Errorf("%w: random source cannot be nil", ErrInvalidArgument)
+	}
+
+	switch mode {
+	case AutoKeyProvidingMode:
+		return newAutoKeySelector(random), nil
+
+	case RandomKeyProvidingMode:
+		return newRandomKeySelector(random), nil
+
+	case NonRepeatingKeyProvidingMode:
+		return newNonRepeatingKeySelector(random), nil
+
+	case CyclicKeyProvidingMode:
+		return NewCyclicKeySelector(), nil
+
+	case NonRepeatingCyclicKeyProvidingMode:
+		return newNonRepeatingCyclicKeySelector(random), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrInvalidKeyProvidingMode, mode)
+	}
+}
+
+// autoKeySelectorState is the selector picked for the current IDs.
+type autoKeySelectorState struct {
+	selector KeySelector
+}
+
+type autoKeySelector struct {
+	random RandomSource
+	state  atomic.Pointer[autoKeySelectorState]
+}
+
+// NewAutoKeySelector returns the KeySelector of AutoKeyProvidingMode, which
+// picks a strategy whenever the IDs are set, based on their number: random for
+// a single key, non-repeating for two to five keys and non-repeating cyclic
+// for more.
+func NewAutoKeySelector() KeySelector {
+	return newAutoKeySelector(defaultRandomSource)
+}
+
+func newAutoKeySelector(random RandomSource) *autoKeySelector {
+	selector := &autoKeySelector{random: random}
+	selector.state.Store(&autoKeySelectorState{selector: newRandomKeySelector(random)})
+
+	return selector
+}
+
+func (s *autoKeySelector) Set(ids ...string) {
+	var selector KeySelector
+	switch len(ids) {
+	case 0, 1:
+		selector = newRandomKeySelector(s.random)
+
+	case 2, 3, 4, 5:
+		selector = newNonRepeatingKeySelector(s.random)
+
+	default:
+		selector = newNonRepeatingCyclicKeySelector(s.random)
+	}
+
+	selector.Set(ids...)
+	s.state.Store(&autoKeySelectorState{selector: selector})
+}
+
+func (s *autoKeySelector) Select() (string, error) {
+	return s.state.Load().selector.Select()
+}
+
+func (s *autoKeySelector) cursor() int {
+	return selectorCursor(s.state.Load().selector)
+}
+
+func (s *autoKeySelector) seek(position int) {
+	if cursor, ok := s.state.Load().selector.(keySelectorCursor); ok {
+		cursor.seek(position)
+	}
+}
+
+type randomKeySelector struct {
+	random RandomSource
+	ids    atomic.Pointer[[]string]
+}
+
+// NewRandomKeySelector returns the KeySelector of RandomKeyProvidingMode, which
+// selects a random ID. The same ID can be selected several times in a row.
+func NewRandomKeySelector() KeySelector {
+	return newRandomKeySelector(defaultRandomSource)
+}
+
+func newRandomKeySelector(random RandomSource) *randomKeySelector {
+	selector := &randomKeySelector{random: random}
+	selector.ids.Store(&[]string{})
+
+	return selector
+}
+
+func (s *randomKeySelector) Set(ids ...string) {
+	ids = slices.Clone(ids)
+	s.ids.Store(&ids)
+}
+
+func (s *randomKeySelector) Select() (string, error) {
+	ids := *s.ids.Load()
+	if len(ids) == 0 {
+		return "", ErrNoKeysGenerated
+	}
+
+	return ids[s.random.Intn(len(ids))], nil
+}
+
+// nonRepeatingKeySelectorState holds the current IDs and the index of the
+// last one selected, or -1.
+type nonRepeatingKeySelectorState struct {
+	ids  []string
+	last atomic.Int64
+}
+
+type nonRepeatingKeySelector struct {
+	random RandomSource
+	state  atomic.Pointer[nonRepeatingKeySelectorState]
+}
+
+// NewNonRepeatingKeySelector returns the KeySelector of
+// NonRepeatingKeyProvidingMode, which selects a random ID other than the
+// previous one.
+func NewNonRepeatingKeySelector() KeySelector {
+	return newNonRepeatingKeySelector(defaultRandomSource)
+}
+
+func newNonRepeatingKeySelector(random RandomSource) *nonRepeatingKeySelector {
+	selector := &nonRepeatingKeySelector{random: random}
+	selector.Set()
+
+	return selector
+}
+
+func (s *nonRepeatingKeySelector) Set(ids ...string) {
+	state := &nonRepeatingKeySelectorState{ids: slices.Clone(ids)}
+	state.last.Store(-1)
+
+	s.state.Store(state)
+}
+
+func (s *nonRepeatingKeySelector) Select() (string, error) {
+	state := s.state.Load()
+
+	switch len(state.ids) {
+	case 0:
+		return "", ErrNoKeysGenerated
+
+	case 1:
+		return state.ids[0], nil
+	}
+
+	for {
+		last := state.last.Load()
+
+		var index int64
+		if last < 0 {
+			index = int64(s.random.Intn(len(state.ids)))
+		} else {
+			index = int64(s.random.Intn(len(state.ids) - 1))
+			if index >= last {
+				index++
+			}
+		}
+
+		// Another goroutine selecting in between must be taken into account.
+		if state.last.CompareAndSwap(last, index) {
+			return state.ids[index], nil
+		}
+	}
+}
+
+// cyclicKeySelectorState holds the current IDs and the number of selections
+// made among them.
+type cyclicKeySelectorState struct {
+	ids  []string
+	next atomic.Uint64
+}
+
+type cyclicKeySelector struct {
+	state atomic.Pointer[cyclicKeySelectorState]
+}
+
+// NewCyclicKeySelector returns the KeySelector of CyclicKeyProvidingMode, which
+// goes through the IDs in order, starting over after the last one.
+func NewCyclicKeySelector() KeySelector {
+	selector := &cyclicKeySelector{}
+	selector.Set()
+
+	return selector
+}
+
+func (s *cyclicKeySelector) Set(ids ...string) {
+	s.state.Store(&cyclicKeySelectorState{ids: slices.Clone(ids)})
+}
+
+func (s *cyclicKeySelector) Select() (string, error) {
+	state := s.state.Load()
+	if len(state.ids) == 0 {
+		return "", ErrNoKeysGenerated
+	}
+
+	next := state.next.Add(1) - 1
+	return state.ids[next%uint64(len(state.ids))], nil
+}
+
+func (s *cyclicKeySelector) cursor() int {
+	state := s.state.Load()
+	if len(state.ids) == 0 {
+		return 0
+	}
+
+	return int(state.next.Load() % uint64(len(state.ids)))
+}
+
+func (s *cyclicKeySelector) seek(cursor int) {
+	state := s.state.Load()
+	if cursor >= 0 && cursor <= len(state.ids) {
+		state.next.Store(uint64(cursor))
+	}
+}
+
+type nonRepeatingCyclicKeySelector struct {
+	random RandomSource
+
+	mu        sync.Mutex
+	ids       []string
+	available []int
+	last      int
+}
+
+// NewNonRepeatingCyclicKeySelector returns the KeySelector of
+// NonRepeatingCyclicKeyProvidingMode, which selects every ID once, in random
+// order, before starting a new cycle, never selecting the same ID twice in a
+// row.
+func NewNonRepeatingCyclicKeySelector() KeySelector {
+	return newNonRepeatingCyclicKeySelector(defaultRandomSource)
+}
+
+func newNonRepeatingCyclicKeySelector(random RandomSource) *nonRepeatingCyclicKeySelector {
+	return &nonRepeatingCyclicKeySelector{random: random, last: -1}
+}
+
+func (s *nonRepeatingCyclicKeySelector) Set(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ids = slices.Clone(ids)
+	s.available = nil
+	s.last = -1
+}
+
+// Select draws the IDs left in the cycle, which, unlike the other selectors'
+// state, cannot be updated atomically, so it is guarded by a mutex.
+func (s *nonRepeatingCyclicKeySelector) Select() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch len(s.ids) {
+	case 0:
+		return "", ErrNoKeysGenerated
+
+	case 1:
+		return s.ids[0], nil
+	}
+
+	if len(s.available) == 0 {
+		s.available = make([]int, len(s.ids))
+		for index := range s.ids {
+			s.available[index] = index
+		}
+	}
+
+	var index int
+	if len(s.available) > 1 {
+		index = s.random.Intn(len(s.available) - 1)
+		if s.available[index] == s.last {
+			index = len(s.available) - 1
+		}
+	}
+
+	s.last = s.available[index]
+
+	end := len(s.available) - 1
+	s.available[index], s.available[end] = s.available[end], s.available[index]
+	s.available = s.available[:end]
+
+	return s.ids[s.last], nil
+}
+
+// rendezvous returns the ID of the given subject by rendezvous (highest random
+// weight) hashing: the ID scoring the highest hash along with the subject wins.
+// Removing an ID only remaps the subjects it won, and adding one only takes
+// over the subjects it now wins. If there are no IDs, it returns an
+// ErrNoKeysGenerated.
+func rendezvous(subject string, ids []string) (string, error) {
+	if len(ids) == 0 {
+		return "", ErrNoKeysGenerated
+	}
+
+	var winner string
+	var best uint64
+	for i, id := range ids {
+		hash := fnv.New64a()
+		hash.Write([]byte(id))
+		hash.Write([]byte{0})
+		hash.Write([]byte(subject))
+
+		score := mix64(hash.Sum64())
+		if i == 0 || score > best || (score == best && id < winner) {
+			winner, best = id, score
+		}
+	}
+
+	return winner, nil
+}
+
+// mix64 is the finalizer of SplitMix64, spreading the FNV hashes of similar
+// inputs over the whole range.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// KeyIDProvider provides key IDs with the built-in KeySelector of a
+// KeyProvidingMode. It is safe for concurrent use, and its zero value uses
+// AutoKeyProvidingMode.
+type KeyIDProvider struct {
+	once     sync.Once
+	selector KeySelector
+	err      error
+}
+
+// NewKeyIDProvider returns a new KeyIDProvider with the specified mode and IDs,
+// using the default RandomSource.
+func NewKeyIDProvider(mode KeyProvidingMode, ids ...string) *KeyIDProvider {
+	return NewKeyIDProviderWithRandom(mode, defaultRandomSource, ids...)
+}
+
+// NewKeyIDProviderWithRandom returns a new KeyIDProvider with the specified
+// mode and IDs, using the given RandomSource.
+func NewKeyIDProviderWithRandom(mode KeyProvidingMode, random RandomSource, ids ...string) *KeyIDProvider {
+	provider := &KeyIDProvider{}
+	provider.once.Do(func() {
+		provider.selector, provider.err = NewKeySelectorWithRandom(mode, random)
+	})
+
+	provider.Set(ids...)
+	return provider
+}
+
+// Set replaces the existing IDs in the KeyIDProvider with the provided IDs.
+// Note that any previous IDs are lost when this method is called.
+func (p *KeyIDProvider) Set(ids ...string) {
+	if selector := p.keySelector(); selector != nil {
+		selector.Set(ids...)
+	}
+}
+
+// Get returns an ID based on the KeyProvidingMode. If no IDs are available,
+// it returns an ErrNoKeysGenerated; if the mode or the RandomSource is
+// invalid, it returns the error of NewKeySelectorWithRandom.
+func (p *KeyIDProvider) Get() (string, error) {
+	selector := p.keySelector()
+	if selector == nil {
+		return "", p.err
+	}
+
+	return selector.Select()
+}
+
+// Select works like Get, so that a KeyIDProvider is a KeySelector as well.
+func (p *KeyIDProvider) Select() (string, error) {
+	return p.Get()
+}
+
+// keySelector returns the selector of the provider, or nil if it could not be
+// created.
+func (p *KeyIDProvider) keySelector() KeySelector {
+	p.once.Do(func() {
+		p.selector = NewAutoKeySelector()
+	})
+
+	return p.selector
+}
@@ -0,0 +1,387 @@
+package krot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLKeyStorageTable is the name of the table holding the keys of the SQL
+// KeyStorage.
+const SQLKeyStorageTable = "krot_keys"
+
+// SQLDialect is the SQL flavour spoken by the database behind a SQL KeyStorage.
+type SQLDialect int
+
+const (
+	// SQLDialectPostgres is the dialect of PostgreSQL, e.g. through the
+	// github.com/lib/pq or github.com/jackc/pgx drivers.
+	SQLDialectPostgres SQLDialect = iota
+
+	// SQLDialectMySQL is the dialect of MySQL and MariaDB, e.g. through the
+	// github.com/go-sql-driver/mysql driver.
+	SQLDialectMySQL
+
+	// SQLDialectSQLite is the dialect of SQLite 3.24 or later, e.g. through the
+	// github.com/mattn/go-sqlite3 or modernc.org/sqlite drivers.
+	SQLDialectSQLite
+)
+
+var sqlDialectNames = map[SQLDialect]string{
+	SQLDialectPostgres: "postgres",
+	SQLDialectMySQL:    "mysql",
+	SQLDialectSQLite:   "sqlite",
+}
+
+// String returns the name of the dialect, e.g. "postgres".
+func (d SQLDialect) String() string {
+	if name, ok := sqlDialectNames[d]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("SQLDialect(%d)", int(d))
+}
+
+// sqlColumns are the columns of the keys table, in the order used by queries.
+var sqlColumns = []string{"id", "value", "expires", "state", "created_at", "activated_at", "retired_at", "revoked_at"}
+
+// migrations returns the statements creating the keys table and its index.
+// Timestamps are stored as microseconds since the Unix epoch, so that they
+// compare the same way on every database and driver.
+func (d SQLDialect) migrations() ([]string, error) {
+	switch d {
+	case SQLDialectPostgres, SQLDialectSQLite:
+		return []string{
+			`CREATE TABLE IF NOT EXISTS ` + SQLKeyStorageTable + ` (
+				id TEXT PRIMARY KEY,
+				value TEXT NOT NULL,
+				expires BIGINT NOT NULL,
+				state VARCHAR(16) NOT NULL,
+				created_at BIGINT,
+				activated_at BIGINT,
+				retired_at BIGINT,
+				revoked_at BIGINT
+			)`,
+			`CREATE INDEX IF NOT EXISTS ` + SQLKeyStorageTable + `_expires ON ` + SQLKeyStorageTable + ` (expires)`,
+		}, nil
+
+	case SQLDialectMySQL:
+		return []string{
+			`CREATE TABLE IF NOT EXISTS ` + SQLKeyStorageTable + ` (
+				id VARCHAR(255) PRIMARY KEY,
+				value MEDIUMTEXT NOT NULL,
+				expires BIGINT NOT NULL,
+				state VARCHAR(16) NOT NULL,
+				created_at BIGINT,
+				activated_at BIGINT,
+				retired_at BIGINT,
+				revoked_at BIGINT,
+				INDEX ` + SQLKeyStorageTable + `_expires (expires)
+			)`,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported SQL dialect %s", ErrInvalidArgument, d)
+	}
+}
+
+// placeholder returns the placeholder of the n-th argument of a query,
+// counting from 1.
+func (d SQLDialect) placeholder(n int) string {
+	if d == SQLDialectPostgres {
+		return fmt.Sprintf("$%d", n)
+	}
+
+	return "?"
+}
+
+// placeholders returns the placeholders of count arguments, starting from the
+// n-th one.
+func (d SQLDialect) placeholders(n, count int) string {
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		placeholders[i] = d.placeholder(n + i)
+	}
+
+	return strings.Join(placeholders, ", ")
+}
+
+// upsert returns the statement inserting a key or replacing the existing one.
+func (d SQLDialect) upsert() string {
+	statement := `INSERT INTO ` + SQLKeyStorageTable + ` (` + strings.Join(sqlColumns, ", ") + `)
+		VALUES (` + d.placeholders(1, len(sqlColumns)) + `)`
+
+	updates := make([]string, 0, len(sqlColumns)-1)
+	for _, column := range sqlColumns[1:] {
+		if d == SQLDialectMySQL {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+	}
+
+	if d == SQLDialectMySQL {
+		return statement + ` ON DUPLICATE KEY UPDATE ` + strings.Join(updates, ", ")
+	}
+
+	return statement + ` ON CONFLICT (id) DO UPDATE SET ` + strings.Join(updates, ", ")
+}
+
+// MigrateSQLKeyStorage creates the table of the SQL KeyStorage, and the index
+// on its expiration column, unless they already exist. It is safe to run on
+// every startup.
+//
+//	err := krot.MigrateSQLKeyStorage(ctx, db, krot.SQLDialectPostgres)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func MigrateSQLKeyStorage(ctx context.Context, db *sql.DB, dialect SQLDialect) error {
+	if db == nil {
+		return fmt.Errorf("%w: database cannot be nil", ErrInvalidArgument)
+	}
+
+	statements, err := dialect.migrations()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type sqlStorage struct {
+	db      *sql.DB
+	dialect SQLDialect
+
+	mutex sync.RWMutex
+	clock Clock
+}
+
+// NewSQLKeyStorage returns a KeyStorage that keeps the keys in the
+// SQLKeyStorageTable table of the given database, which must be created with
+// MigrateSQLKeyStorage beforehand.
+//
+// Key values are encoded with MarshalKeyValue, so that values of a type with a
+// registered KeyCodec, such as []byte secrets and *KeyPair, are read back with
+// their type, while other values are stored as plain JSON. Timestamps are
+// stored with microsecond precision.
+//
+//	db, err := sql.Open("pgx", dsn)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	storage := krot.NewSQLKeyStorage(db, krot.SQLDialectPostgres)
+func NewSQLKeyStorage(db *sql.DB, dialect SQLDialect) KeyStorage {
+	return &sqlStorage{db: db, dialect: dialect, clock: NewClock()}
+}
+
+func (s *sqlStorage) setClock(clock Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clock = clock
+}
+
+func (s *sqlStorage) now() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.clock.Now()
+}
+
+func (s *sqlStorage) Get(ctx context.Context, id string) (*Key, error) {
+	query := `SELECT ` + strings.Join(sqlColumns, ", ") + ` FROM ` + SQLKeyStorageTable +
+		` WHERE id = ` + s.dialect.placeholder(1)
+
+	key, err := scanSQLKey(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Join(ErrKeyNotFound, fmt.Errorf("key %s not found", id))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *sqlStorage) Add(ctx context.Context, keys ...*Key) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statement, err := tx.PrepareContext(ctx, s.dialect.upsert())
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	for _, key := range keys {
+		if key == nil || key.ID == "" || key.Value == "" {
+			continue
+		}
+
+		value, err := MarshalKeyValue(key.Value)
+		if err != nil {
+			return fmt.Errorf("key %s value: %w", key.ID, err)
+		}
+
+		state, err := key.State.MarshalText()
+		if err != nil {
+			return fmt.Errorf("key %s state: %w", key.ID, err)
+		}
+
+		_, err = statement.ExecContext(ctx,
+			key.ID,
+			string(value),
+			key.Expires.UnixMicro(),
+			string(state),
+			sqlTime(key.CreatedAt),
+			sqlTime(key.ActivatedAt),
+			sqlTime(key.RetiredAt),
+			sqlTime(key.RevokedAt),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStorage) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	statement := `DELETE FROM ` + SQLKeyStorageTable +
+		` WHERE id IN (` + s.dialect.placeholders(1, len(ids)) + `)`
+
+	_, err := s.db.ExecContext(ctx, statement, args...)
+	return err
+}
+
+func (s *sqlStorage) List(ctx context.Context, options *KeyListOptions) ([]*Key, error) {
+	now := s.now()
+
+	query := `SELECT ` + strings.Join(sqlColumns, ", ") + ` FROM ` + SQLKeyStorageTable
+	args := []any{}
+	if options == nil || !options.IncludeExpired {
+		query += ` WHERE expires >= ` + s.dialect.placeholder(1)
+		args = append(args, now.UnixMicro())
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*Key{}
+	for rows.Next() {
+		key, err := scanSQLKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return options.apply(keys, now), nil
+}
+
+// ClearDeprecated removes the expired keys, relying on the index on their
+// expiration.
+func (s *sqlStorage) ClearDeprecated(ctx context.Context) error {
+	_, err := s.PurgeDeprecated(ctx)
+	return err
+}
+
+// PurgeDeprecated works like ClearDeprecated, returning the number of rows
+// affected by the deletion.
+func (s *sqlStorage) PurgeDeprecated(ctx context.Context) (int, error) {
+	statement := `DELETE FROM ` + SQLKeyStorageTable + ` WHERE expires < ` + s.dialect.placeholder(1)
+
+	result, err := s.db.ExecContext(ctx, statement, s.now().UnixMicro())
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+func (s *sqlStorage) Erase(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM `+SQLKeyStorageTable)
+	return err
+}
+
+// scanSQLKey reads a key from a row holding the sqlColumns.
+func scanSQLKey(row interface{ Scan(dest ...any) error }) (*Key, error) {
+	var (
+		key                                          Key
+		value, state                                 string
+		expires                                      int64
+		createdAt, activatedAt, retiredAt, revokedAt sql.NullInt64
+	)
+
+	if err := row.Scan(&key.ID, &value, &expires, &state, &createdAt, &activatedAt, &retiredAt, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	decoded, err := UnmarshalKeyValue([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("key %s value is corrupted: %w", key.ID, err)
+	}
+	key.Value = decoded
+
+	if err := key.State.UnmarshalText([]byte(state)); err != nil {
+		return nil, err
+	}
+
+	key.Expires = time.UnixMicro(expires)
+	key.CreatedAt = timeFromSQL(createdAt)
+	key.ActivatedAt = timeFromSQL(activatedAt)
+	key.RetiredAt = timeFromSQL(retiredAt)
+	key.RevokedAt = timeFromSQL(revokedAt)
+
+	return &key, nil
+}
+
+// sqlTime stores zero times as NULL.
+func sqlTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: t.UnixMicro(), Valid: true}
+}
+
+func timeFromSQL(t sql.NullInt64) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+
+	return time.UnixMicro(t.Int64)
+}
@@ -0,0 +1,153 @@
+package krot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotatorSnapshot is the metadata a Rotator persists in its RotatorStateStore
+// after each rotation, so that a restarted rotator resumes with the keys it was
+// using instead of generating a new batch.
+type RotatorSnapshot struct {
+	// ActiveKeyIDs are the IDs of the keys handed out for signing.
+	ActiveKeyIDs []string `json:"active_key_ids"`
+
+	// PendingKeyIDs are the IDs of the keys published ahead of their activation.
+	PendingKeyIDs []string `json:"pending_key_ids,omitempty"`
+
+	// RotatedAt is the time of the last rotation.
+	RotatedAt time.Time `json:"rotated_at"`
+
+	// ScheduledAt is the time of the next scheduled rotation.
+	ScheduledAt time.Time `json:"scheduled_at"`
+
+	// ProviderCursor is the position of the KeySelector in ActiveKeyIDs, so
+	// that cyclic selectors carry on where they stopped.
+	ProviderCursor int `json:"provider_cursor"`
+}
+
+// RotatorStateStore persists the metadata of a Rotator across restarts.
+// It is set with Rotator.SetStateStore.
+type RotatorStateStore interface {
+	// Load returns the last saved snapshot, or an ErrStateNotFound if none was
+	// saved yet.
+	//
+	//     snapshot, err := store.Load(ctx)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	Load(context context.Context) (*RotatorSnapshot, error)
+
+	// Save replaces the saved snapshot with the given one.
+	//
+	//     err := store.Save(ctx, snapshot)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	Save(context context.Context, snapshot *RotatorSnapshot) error
+}
+
+type inMemoryStateStore struct {
+	mutex    sync.Mutex
+	snapshot *RotatorSnapshot
+}
+
+// NewRotatorStateStore returns an in-memory RotatorStateStore. It survives a
+// Stop and Start of the rotators using it, but not a restart of the process.
+func NewRotatorStateStore() RotatorStateStore {
+	return &inMemoryStateStore{}
+}
+
+func (s *inMemoryStateStore) Load(ctx context.Context) (*RotatorSnapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.snapshot == nil {
+		return nil, ErrStateNotFound
+	}
+
+	return s.snapshot.clone(), nil
+}
+
+func (s *inMemoryStateStore) Save(ctx context.Context, snapshot *RotatorSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("%w: snapshot cannot be nil", ErrInvalidArgument)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.snapshot = snapshot.clone()
+	return nil
+}
+
+type fileStateStore struct {
+	path string
+}
+
+// NewFileRotatorStateStore returns a RotatorStateStore that keeps the snapshot
+// as JSON in the file at the given path. The file is created with 0600
+// permissions and replaced atomically on every save.
+func NewFileRotatorStateStore(path string) RotatorStateStore {
+	return &fileStateStore{path: path}
+}
+
+func (s *fileStateStore) Load(ctx context.Context) (*RotatorSnapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrStateNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &RotatorSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("rotator state %s is corrupted: %w", s.path, err)
+	}
+
+	return snapshot, nil
+}
+
+func (s *fileStateStore) Save(ctx context.Context, snapshot *RotatorSnapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("%w: snapshot cannot be nil", ErrInvalidArgument)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data, 0o600)
+}
+
+func (s *RotatorSnapshot) clone() *RotatorSnapshot {
+	clone := *s
+	clone.ActiveKeyIDs = append([]string(nil), s.ActiveKeyIDs...)
+	clone.PendingKeyIDs = append([]string(nil), s.PendingKeyIDs...)
+
+	return &clone
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 )
 
 // KeyStorage defines the interface for key storage operations. It provides methods
@@ -55,17 +59,163 @@ type KeyStorage interface {
 	Erase(context context.Context) error
 }
 
+// KeyListOptions filters and pages the keys returned by a KeyLister. Keys are
+// listed ordered by expiration and then by ID, so pages are stable as long as
+// the storage is not modified.
+type KeyListOptions struct {
+	// Offset is the number of matching keys to skip.
+	Offset int
+
+	// Limit is the maximum number of keys to return. Zero means no limit.
+	Limit int
+
+	// IncludeExpired determines if expired keys are listed as well.
+	IncludeExpired bool
+
+	// ExpiresAfter, if set, only lists keys expiring after the given time.
+	ExpiresAfter time.Time
+
+	// ExpiresBefore, if set, only lists keys expiring before the given time.
+	ExpiresBefore time.Time
+
+	// States, if set, only lists keys in one of the given states.
+	States []KeyState
+}
+
+func (o *KeyListOptions) match(key *Key, now time.Time) bool {
+	if key == nil {
+		return false
+	}
+
+	if !o.IncludeExpired && key.ExpiredAt(now) {
+		return false
+	}
+
+	if !o.ExpiresAfter.IsZero() && !key.Expires.After(o.ExpiresAfter) {
+		return false
+	}
+
+	if !o.ExpiresBefore.IsZero() && !key.Expires.Before(o.ExpiresBefore) {
+		return false
+	}
+
+	if len(o.States) > 0 && !slices.Contains(o.States, key.State) {
+		return false
+	}
+
+	return true
+}
+
+// apply sorts the keys matching the options at the given time and returns the
+// requested page.
+func (o *KeyListOptions) apply(keys []*Key, now time.Time) []*Key {
+	if o == nil {
+		o = &KeyListOptions{}
+	}
+
+	matches := make([]*Key, 0, len(keys))
+	for _, key := range keys {
+		if o.match(key, now) {
+			matches = append(matches, key)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].Expires.Equal(matches[j].Expires) {
+			return matches[i].Expires.Before(matches[j].Expires)
+		}
+
+		return matches[i].ID < matches[j].ID
+	})
+
+	if o.Offset >= len(matches) {
+		return []*Key{}
+	}
+
+	matches = matches[max(o.Offset, 0):]
+	if o.Limit > 0 && o.Limit < len(matches) {
+		matches = matches[:o.Limit]
+	}
+
+	return matches
+}
+
+// KeyLister is an optional interface for key storages that are able to
+// enumerate the keys they hold. Storages that do not implement it keep
+// working, but the Rotator can then only enumerate the keys it generated.
+type KeyLister interface {
+	// List returns the keys in the storage that match the given options. A nil
+	// options value lists every non-expired key.
+	//
+	//     keys, err := storage.(krot.KeyLister).List(ctx, &krot.KeyListOptions{Limit: 10})
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	List(context context.Context, options *KeyListOptions) ([]*Key, error)
+}
+
+// KeyWatcher is an optional interface for key storages that are able to notify
+// changes to the keys they hold. Rotators in Follower mode use it to reload the
+// key set as soon as the writer rotates it.
+type KeyWatcher interface {
+	// Watch returns a channel that receives a value whenever keys are added,
+	// updated or removed. Notifications may be coalesced. The channel is closed
+	// once the context is done.
+	//
+	//     changes, err := storage.(krot.KeyWatcher).Watch(ctx)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	Watch(context context.Context) (<-chan struct{}, error)
+}
+
+// KeyPurger is an optional interface for key storages that are able to report
+// how many expired keys they remove. The key cleaner of a Rotator uses it to
+// fill in the KeysCleaned events.
+type KeyPurger interface {
+	// PurgeDeprecated works like ClearDeprecated, returning the number of
+	// removed keys.
+	//
+	//     count, err := storage.(krot.KeyPurger).PurgeDeprecated(ctx)
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	PurgeDeprecated(context context.Context) (int, error)
+}
+
 type inMemoryStorage struct {
-	storage map[string]*Key
+	mutex    sync.RWMutex
+	storage  map[string]*Key
+	clock    Clock
+	watchers map[chan struct{}]struct{}
 }
 
+// NewKeyStorage returns an in-memory KeyStorage that is safe for concurrent use.
 func NewKeyStorage() KeyStorage {
+	return NewKeyStorageWithClock(NewClock())
+}
+
+// NewKeyStorageWithClock returns an in-memory KeyStorage that tells expired
+// keys apart using the given clock.
+func NewKeyStorageWithClock(clock Clock) KeyStorage {
 	return &inMemoryStorage{
-		storage: make(map[string]*Key),
+		storage:  make(map[string]*Key),
+		clock:    clock,
+		watchers: make(map[chan struct{}]struct{}),
 	}
 }
 
+func (s *inMemoryStorage) setClock(clock Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clock = clock
+}
+
 func (s *inMemoryStorage) Get(_ context.Context, id string) (*Key, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
 	key, ok := s.storage[id]
 	if !ok {
 		return nil, errors.Join(ErrKeyNotFound, fmt.Errorf("key %s not found", id))
@@ -75,6 +225,9 @@ func (s *inMemoryStorage) Get(_ context.Context, id string) (*Key, error) {
 }
 
 func (s *inMemoryStorage) Add(_ context.Context, keys ...*Key) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	for _, key := range keys {
 		if key == nil || key.ID == "" || key.Value == "" {
 			continue
@@ -83,28 +236,176 @@ func (s *inMemoryStorage) Add(_ context.Context, keys ...*Key) error {
 		s.storage[key.ID] = key
 	}
 
+	s.notify()
 	return nil
 }
 
 func (s *inMemoryStorage) Delete(_ context.Context, ids ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	for _, keyID := range ids {
 		delete(s.storage, keyID)
 	}
 
+	s.notify()
 	return nil
 }
 
-func (s *inMemoryStorage) ClearDeprecated(_ context.Context) error {
+func (s *inMemoryStorage) List(_ context.Context, options *KeyListOptions) ([]*Key, error) {
+	s.mutex.RLock()
+	keys := make([]*Key, 0, len(s.storage))
+	for _, key := range s.storage {
+		keys = append(keys, key)
+	}
+	now := s.clock.Now()
+	s.mutex.RUnlock()
+
+	return options.apply(keys, now), nil
+}
+
+func (s *inMemoryStorage) ClearDeprecated(ctx context.Context) error {
+	_, err := s.PurgeDeprecated(ctx)
+	return err
+}
+
+func (s *inMemoryStorage) PurgeDeprecated(_ context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	now := s.clock.Now()
 	for key, value := range s.storage {
-		if value == nil || value.Expired() {
+		if value == nil || value.ExpiredAt(now) {
 			delete(s.storage, key)
+			count++
 		}
 	}
 
-	return nil
+	if count > 0 {
+		s.notify()
+	}
+
+	return count, nil
 }
 
 func (s *inMemoryStorage) Erase(_ context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	s.storage = make(map[string]*Key)
+	s.notify()
+
 	return nil
 }
+
+func (s *inMemoryStorage) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+
+	s.mutex.Lock()
+	s.watchers[changes] = struct{}{}
+	s.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mutex.Lock()
+		delete(s.watchers, changes)
+		close(changes)
+		s.mutex.Unlock()
+	}()
+
+	return changes, nil
+}
+
+// notify wakes the watchers up without blocking: a watcher that has not
+// consumed its previous notification yet will see the changes anyway.
+// The mutex must be locked.
+func (s *inMemoryStorage) notify() {
+	for watcher := range s.watchers {
+		select {
+		case watcher <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// wrappingStorage is implemented by the storages of this package that wrap
+// another KeyStorage. They implement every optional interface on top of the
+// wrapped storage, which may not.
+type wrappingStorage interface {
+	KeyStorage
+	KeyLister
+	KeyWatcher
+	KeyPurger
+	clockSetter
+}
+
+// wrappedStorage is the part of a wrappingStorage exposed regardless of the
+// wrapped storage.
+type wrappedStorage interface {
+	KeyStorage
+	clockSetter
+}
+
+// exposeOptional returns the wrapping storage behind a value implementing only
+// the optional interfaces that the wrapped storage implements as well, so that
+// callers can keep detecting them with type assertions.
+func exposeOptional(wrapping wrappingStorage, wrapped KeyStorage) KeyStorage {
+	_, lister := wrapped.(KeyLister)
+	_, watcher := wrapped.(KeyWatcher)
+	_, purger := wrapped.(KeyPurger)
+
+	switch {
+	case lister && watcher && purger:
+		return wrapping
+
+	case lister && watcher:
+		return struct {
+			wrappedStorage
+			KeyLister
+			KeyWatcher
+		}{wrapping, wrapping, wrapping}
+
+	case lister && purger:
+		return struct {
+			wrappedStorage
+			KeyLister
+			KeyPurger
+		}{wrapping, wrapping, wrapping}
+
+	case watcher && purger:
+		return struct {
+			wrappedStorage
+			KeyWatcher
+			KeyPurger
+		}{wrapping, wrapping, wrapping}
+
+	case lister:
+		return struct {
+			wrappedStorage
+			KeyLister
+		}{wrapping, wrapping}
+
+	case watcher:
+		return struct {
+			wrappedStorage
+			KeyWatcher
+		}{wrapping, wrapping}
+
+	case purger:
+		return struct {
+			wrappedStorage
+			KeyPurger
+		}{wrapping, wrapping}
+
+	default:
+		return struct {
+			wrappedStorage
+		}{wrapping}
+	}
+}
@@ -0,0 +1,177 @@
+package krot_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+type countingStorage struct {
+	krot.KeyStorage
+	gets atomic.Int64
+}
+
+func (s *countingStorage) Get(ctx context.Context, id string) (*krot.Key, error) {
+	s.gets.Add(1)
+	return s.KeyStorage.Get(ctx, id)
+}
+
+func TestCachedKeyStorage(t *testing.T) {
+	ctx := context.Background()
+
+	newStorage := func(settings *krot.KeyCacheSettings) (*countingStorage, krot.KeyStorage, *krottest.Clock) {
+		clock := krottest.NewClock(time.Now())
+		inner := &countingStorage{KeyStorage: krot.NewKeyStorageWithClock(clock)}
+
+		storage := krot.NewCachedKeyStorage(inner, settings)
+
+		rotator, err := krot.NewWithSettings(&krot.RotatorSettings{
+			RotationKeyCount: 1,
+			RotationInterval: time.Hour,
+			KeyExpiration:    time.Hour,
+			Clock:            clock,
+		})
+		assert.NoError(t, err)
+
+		// Sharing the rotator's clock with the cache.
+		assert.NoError(t, rotator.SetStorage(storage))
+		return inner, storage, clock
+	}
+
+	t.Run("Should serve keys from the cache", func(t *testing.T) {
+		inner, storage, clock := newStorage(nil)
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "1", Value: "1", Expires: clock.Now().Add(time.Hour)}))
+
+		for i := 0; i < 10; i++ {
+			key, err := storage.Get(ctx, "1")
+			assert.NoError(t, err)
+			assert.Equal(t, "1", key.Value)
+		}
+
+		assert.EqualValues(t, 1, inner.gets.Load())
+	})
+
+	t.Run("Should expire entries after the TTL", func(t *testing.T) {
+		inner, storage, clock := newStorage(&krot.KeyCacheSettings{TTL: time.Minute})
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "1", Value: "1", Expires: clock.Now().Add(time.Hour)}))
+
+		storage.Get(ctx, "1")
+		clock.Advance(time.Minute)
+		storage.Get(ctx, "1")
+
+		assert.EqualValues(t, 2, inner.gets.Load())
+	})
+
+	t.Run("Should not cache keys beyond their expiration", func(t *testing.T) {
+		inner, storage, clock := newStorage(&krot.KeyCacheSettings{TTL: time.Hour})
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "1", Value: "1", Expires: clock.Now().Add(time.Minute)}))
+
+		storage.Get(ctx, "1")
+		clock.Advance(time.Minute)
+		storage.Get(ctx, "1")
+
+		assert.EqualValues(t, 2, inner.gets.Load())
+	})
+
+	t.Run("Should cache missing keys", func(t *testing.T) {
+		inner, storage, clock := newStorage(&krot.KeyCacheSettings{NegativeTTL: time.Second})
+
+		for i := 0; i < 3; i++ {
+			_, err := storage.Get(ctx, "missing")
+			assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+		}
+		assert.EqualValues(t, 1, inner.gets.Load())
+
+		clock.Advance(time.Second)
+		_, err := storage.Get(ctx, "missing")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+		assert.EqualValues(t, 2, inner.gets.Load())
+
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "missing", Value: "found", Expires: clock.Now().Add(time.Hour)}))
+
+		key, err := storage.Get(ctx, "missing")
+		assert.NoError(t, err)
+		assert.Equal(t, "found", key.Value)
+	})
+
+	t.Run("Should evict the least recently used keys", func(t *testing.T) {
+		inner, storage, clock := newStorage(&krot.KeyCacheSettings{Size: 2})
+		for i := 1; i <= 3; i++ {
+			id := fmt.Sprint(i)
+			assert.NoError(t, storage.Add(ctx, &krot.Key{ID: id, Value: id, Expires: clock.Now().Add(time.Hour)}))
+		}
+
+		storage.Get(ctx, "1")
+		storage.Get(ctx, "2")
+		storage.Get(ctx, "1")
+		storage.Get(ctx, "3") // evicts 2
+		assert.EqualValues(t, 3, inner.gets.Load())
+
+		storage.Get(ctx, "1")
+		assert.EqualValues(t, 3, inner.gets.Load())
+
+		storage.Get(ctx, "2")
+		assert.EqualValues(t, 4, inner.gets.Load())
+	})
+
+	t.Run("Should invalidate entries on writes", func(t *testing.T) {
+		_, storage, clock := newStorage(nil)
+		expires := clock.Now().Add(time.Hour)
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "1", Value: "1", Expires: expires}))
+
+		storage.Get(ctx, "1")
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "1", Value: "1", Expires: expires, State: krot.KeyStateRevoked}))
+
+		key, err := storage.Get(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, krot.KeyStateRevoked, key.State)
+
+		assert.NoError(t, storage.Delete(ctx, "1"))
+		_, err = storage.Get(ctx, "1")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "2", Value: "2", Expires: expires}))
+		storage.Get(ctx, "2")
+		assert.NoError(t, storage.Erase(ctx))
+		_, err = storage.Get(ctx, "2")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+
+	t.Run("Should serve the rotator's keys", func(t *testing.T) {
+		inner := &countingStorage{KeyStorage: krot.NewKeyStorage()}
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(krot.NewCachedKeyStorage(inner, nil)))
+		assert.NoError(t, rotator.Rotate())
+
+		for i := 0; i < 100; i++ {
+			_, err := rotator.GetKey()
+			assert.NoError(t, err)
+		}
+
+		assert.LessOrEqual(t, inner.gets.Load(), int64(rotator.RotationKeyCount()))
+	})
+
+	t.Run("Should only implement the optional interfaces of the underlying storage", func(t *testing.T) {
+		storage := krot.NewCachedKeyStorage(krot.NewKeyStorage(), nil)
+		assert.Implements(t, (*krot.KeyLister)(nil), storage)
+		assert.Implements(t, (*krot.KeyWatcher)(nil), storage)
+		assert.Implements(t, (*krot.KeyPurger)(nil), storage)
+
+		storage = krot.NewCachedKeyStorage(&unlistableStorage{krot.NewKeyStorage()}, nil)
+		_, ok := storage.(krot.KeyLister)
+		assert.False(t, ok)
+
+		_, ok = storage.(krot.KeyWatcher)
+		assert.False(t, ok)
+
+		_, ok = storage.(krot.KeyPurger)
+		assert.False(t, ok)
+	})
+}
@@ -1,10 +1,74 @@
 package krot_test
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
 )
 
 // Should group the tests in nested subtests
 func TestKeyCleaner(t *testing.T) {
-	t.Skip("TODO")
+	ctx := context.Background()
+
+	t.Run("Should clear expired keys periodically", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+		storage := krot.NewKeyStorageWithClock(clock)
+		storage.Add(
+			ctx,
+			&krot.Key{ID: "1", Value: "1", Expires: clock.Now().Add(time.Minute)},
+			&krot.Key{ID: "2", Value: "2", Expires: clock.Now().Add(time.Hour)},
+		)
+
+		cleaned := make(chan struct{}, 1)
+		cleaner := krot.NewKeyCleanerWithClock(storage, clock)
+		cleaner.AfterCleaning(func(krot.KeyCleaner) { cleaned <- struct{}{} })
+
+		assert.NoError(t, cleaner.Start(ctx, 10*time.Minute))
+		defer cleaner.Stop()
+		assert.Equal(t, krot.KeyCleanerStatusStarted, cleaner.Status())
+
+		clock.BlockUntil(1)
+		clock.Advance(5 * time.Minute)
+		assert.Equal(t, 1, clock.Waiters())
+
+		_, err := storage.Get(ctx, "1")
+		assert.NoError(t, err)
+
+		clock.Advance(5 * time.Minute)
+		<-cleaned
+
+		_, err = storage.Get(ctx, "1")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+
+		_, err = storage.Get(ctx, "2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should run hooks", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+		cleaner := krot.NewKeyCleanerWithClock(krot.NewKeyStorageWithClock(clock), clock)
+
+		events := make(chan string, 4)
+		cleaner.OnStart(func(krot.KeyCleaner) { events <- "start" })
+		cleaner.BeforeCleaning(func(krot.KeyCleaner) { events <- "before" })
+		cleaner.AfterCleaning(func(krot.KeyCleaner) { events <- "after" })
+		cleaner.OnStop(func(krot.KeyCleaner) { events <- "stop" })
+
+		assert.NoError(t, cleaner.Start(ctx, time.Minute))
+		assert.Error(t, cleaner.Start(ctx, time.Minute))
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		assert.Equal(t, "start", <-events)
+		assert.Equal(t, "before", <-events)
+		assert.Equal(t, "after", <-events)
+
+		cleaner.Stop()
+		assert.Equal(t, "stop", <-events)
+		assert.Equal(t, krot.KeyCleanerStatusStopped, cleaner.Status())
+	})
 }
@@ -0,0 +1,121 @@
+package krot_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+)
+
+// publicHalf returns the public half of a bare crypto key.
+func publicHalf(key any) interface{ Equal(crypto.PublicKey) bool } {
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+
+	return key.(interface{ Equal(crypto.PublicKey) bool })
+}
+
+func TestKeyCodecs(t *testing.T) {
+	roundTrip := func(t *testing.T, value any) any {
+		data, err := json.Marshal(&krot.Key{ID: "1", Value: value})
+		assert.NoError(t, err)
+
+		key := &krot.Key{}
+		assert.NoError(t, json.Unmarshal(data, key))
+		return key.Value
+	}
+
+	t.Run("Should preserve the values of every generator", func(t *testing.T) {
+		generators := map[string]krot.KeyGenerator{
+			"secret":  krot.NewKeyGenerator(krot.KeySize256),
+			"RSA":     krot.NewRSAKeyGenerator(krot.RSAKeySize2048),
+			"ECDSA":   krot.NewECDSAKeyGenerator(krot.ECDSACurveP384),
+			"Ed25519": krot.NewEd25519KeyGenerator(),
+		}
+
+		for name, generator := range generators {
+			value, err := generator.Generate()
+			assert.NoError(t, err, name)
+
+			decoded := roundTrip(t, value)
+			assert.IsType(t, value, decoded, name)
+
+			if pair, ok := value.(*krot.KeyPair); ok {
+				decodedPair := decoded.(*krot.KeyPair)
+				assert.Equal(t, pair.Algorithm, decodedPair.Algorithm, name)
+				assert.True(t, publicHalf(pair.Private).Equal(decodedPair.Public), name)
+				assert.True(t, publicHalf(pair.Public).Equal(publicHalf(decodedPair.Private)), name)
+				continue
+			}
+
+			assert.Equal(t, value, decoded, name)
+		}
+	})
+
+	t.Run("Should preserve bare crypto keys", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+
+		ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+
+		values := []any{
+			rsaKey, &rsaKey.PublicKey,
+			ecdsaKey, &ecdsaKey.PublicKey,
+			privateKey, publicKey,
+		}
+
+		for _, value := range values {
+			decoded := roundTrip(t, value)
+			assert.IsType(t, value, decoded)
+			assert.True(t, publicHalf(value).Equal(publicHalf(decoded)), "%T", value)
+		}
+	})
+
+	t.Run("Should encode bytes as base64 by default", func(t *testing.T) {
+		data, err := krot.MarshalKeyValue([]byte("secret"))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"codec":"base64","data":"c2VjcmV0"}`, string(data))
+
+		value, err := krot.UnmarshalKeyValue([]byte(`{"codec":"hex","data":"736563726574"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("secret"), value)
+	})
+
+	t.Run("Should decode untagged values as plain JSON", func(t *testing.T) {
+		key := &krot.Key{}
+		assert.NoError(t, json.Unmarshal([]byte(`{"id":"1","value":"secret"}`), key))
+		assert.Equal(t, "secret", key.Value)
+
+		value, err := krot.UnmarshalKeyValue([]byte(`{"kty":"oct"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"kty": "oct"}, value)
+	})
+
+	t.Run("Should reject unknown codecs", func(t *testing.T) {
+		_, err := krot.UnmarshalKeyValue([]byte(`{"codec":"unknown","data":""}`))
+		assert.ErrorIs(t, err, krot.ErrUnsupportedKeyType)
+	})
+
+	t.Run("Should encode values with the last registered codec", func(t *testing.T) {
+		assert.NoError(t, krot.RegisterKeyCodec(krot.NewHexKeyCodec()))
+		defer krot.RegisterKeyCodec(krot.NewBase64KeyCodec())
+
+		data, err := krot.MarshalKeyValue([]byte("secret"))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"codec":"hex","data":"736563726574"}`, string(data))
+
+		assert.ErrorIs(t, krot.RegisterKeyCodec(nil), krot.ErrInvalidArgument)
+	})
+}
@@ -0,0 +1,107 @@
+package krot_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+)
+
+// These tests hammer the rotator and its default storage from many goroutines.
+// They are meant to be run with the race detector:
+//
+//	go test -race ./test/...
+const (
+	stressWorkers    = 8
+	stressIterations = 200
+)
+
+func stress(t *testing.T, workers int, task func(worker, iteration int)) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for iteration := 0; iteration < stressIterations; iteration++ {
+				task(worker, iteration)
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+}
+
+func TestInMemoryKeyStorageConcurrency(t *testing.T) {
+	ctx := context.Background()
+	storage := krot.NewKeyStorage()
+
+	stress(t, stressWorkers, func(worker, iteration int) {
+		id := fmt.Sprintf("%d:%d", worker, iteration)
+		expires := time.Now().Add(time.Duration(iteration%2*2-1) * time.Minute)
+
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: id, Value: id, Expires: expires}))
+
+		if _, err := storage.Get(ctx, id); err != nil {
+			assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+		}
+
+		_, err := storage.(krot.KeyLister).List(ctx, nil)
+		assert.NoError(t, err)
+
+		switch iteration % 10 {
+		case 3:
+			assert.NoError(t, storage.Delete(ctx, id))
+		case 6:
+			assert.NoError(t, storage.ClearDeprecated(ctx))
+		case 9:
+			if worker == 0 {
+				assert.NoError(t, storage.Erase(ctx))
+			}
+		}
+	})
+}
+
+func TestRotatorConcurrency(t *testing.T) {
+	ctx := context.Background()
+	storage := krot.NewKeyStorage()
+
+	rotator := krot.New()
+	assert.NoError(t, rotator.SetStorage(storage))
+	assert.NoError(t, rotator.Rotate())
+
+	cleaner := krot.NewKeyCleaner(storage)
+	assert.NoError(t, cleaner.Start(ctx, time.Millisecond))
+	defer cleaner.Stop()
+
+	stress(t, stressWorkers, func(worker, iteration int) {
+		switch worker % 4 {
+		case 0:
+			assert.NoError(t, rotator.Rotate())
+
+		case 1:
+			key, err := rotator.GetKey()
+			assert.NoError(t, err)
+			assert.NotNil(t, key)
+
+		case 2:
+			id, err := rotator.GetKeyID()
+			assert.NoError(t, err)
+
+			if _, err := rotator.GetKeyByID(id); err != nil {
+				assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+			}
+
+		case 3:
+			assert.NoError(t, storage.ClearDeprecated(ctx))
+
+			_, err := rotator.Keys(ctx)
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package krot_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestKeyStorageConformance(t *testing.T) {
+	storages := map[string]krottest.StorageFactory{
+		"InMemory": func(t *testing.T) krot.KeyStorage {
+			return krot.NewKeyStorage()
+		},
+		"File": func(t *testing.T) krot.KeyStorage {
+			return krot.NewFileKeyStorage(filepath.Join(t.TempDir(), "keys.json"))
+		},
+		"SQL": func(t *testing.T) krot.KeyStorage {
+			return krot.NewSQLKeyStorage(newSQLiteDB(t), krot.SQLDialectSQLite)
+		},
+		"Encrypted": func(t *testing.T) krot.KeyStorage {
+			return krot.NewEncryptedKeyStorage(krot.NewKeyStorage(), krottest.NewKMS())
+		},
+		"Cached": func(t *testing.T) krot.KeyStorage {
+			return krot.NewCachedKeyStorage(krot.NewKeyStorage(), nil)
+		},
+	}
+
+	for name, factory := range storages {
+		t.Run(name, func(t *testing.T) {
+			krottest.RunStorageConformance(t, factory)
+		})
+	}
+}
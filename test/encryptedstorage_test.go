@@ -0,0 +1,180 @@
+package krot_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestEncryptedKeyStorage(t *testing.T) {
+	ctx := context.Background()
+
+	inner := krot.NewKeyStorage()
+	kms := krottest.NewKMS()
+	storage := krot.NewEncryptedKeyStorage(inner, kms)
+
+	expires := time.Now().Add(time.Hour)
+	assert.NoError(t, storage.Add(ctx,
+		&krot.Key{ID: "1", Value: "secret-1", Expires: expires},
+		&krot.Key{ID: "2", Value: "secret-2", Expires: expires},
+	))
+
+	t.Run("Should decrypt keys", func(t *testing.T) {
+		key, err := storage.Get(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret-1", key.Value)
+	})
+
+	t.Run("Should not store values in the clear", func(t *testing.T) {
+		key, err := inner.Get(ctx, "1")
+		assert.NoError(t, err)
+		assert.IsType(t, "", key.Value)
+		assert.NotContains(t, key.Value, "secret-1")
+		assert.Equal(t, expires, key.Expires)
+	})
+
+	t.Run("Should list decrypted keys", func(t *testing.T) {
+		keys, err := storage.(krot.KeyLister).List(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+		assert.Equal(t, "secret-1", keys[0].Value)
+	})
+
+	t.Run("Should decrypt keys after a KEK rotation", func(t *testing.T) {
+		kms.RotateKEK()
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "3", Value: "secret-3", Expires: expires}))
+
+		for id, value := range map[string]string{"1": "secret-1", "3": "secret-3"} {
+			key, err := storage.Get(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, value, key.Value)
+		}
+	})
+
+	t.Run("Should detect values moved to another key", func(t *testing.T) {
+		key, err := inner.Get(ctx, "2")
+		assert.NoError(t, err)
+		assert.NoError(t, inner.Add(ctx, &krot.Key{ID: "4", Value: key.Value, Expires: expires}))
+
+		_, err = storage.Get(ctx, "4")
+		assert.ErrorIs(t, err, krot.ErrKeyDecryptionFailed)
+	})
+
+	t.Run("Should reject values stored in the clear", func(t *testing.T) {
+		assert.NoError(t, inner.Add(ctx, &krot.Key{ID: "5", Value: "plain", Expires: expires}))
+
+		_, err := storage.Get(ctx, "5")
+		assert.ErrorIs(t, err, krot.ErrKeyDecryptionFailed)
+	})
+
+	t.Run("Should report KMS failures", func(t *testing.T) {
+		failure := errors.New("KMS unavailable")
+		kms.Fail(failure)
+		defer kms.Fail(nil)
+
+		_, err := storage.Get(ctx, "1")
+		assert.ErrorIs(t, err, failure)
+
+		err = storage.Add(ctx, &krot.Key{ID: "6", Value: "secret-6", Expires: expires})
+		assert.ErrorIs(t, err, failure)
+	})
+
+	t.Run("Should only implement the optional interfaces of the underlying storage", func(t *testing.T) {
+		storage := krot.NewEncryptedKeyStorage(&unlistableStorage{krot.NewKeyStorage()}, kms)
+
+		_, ok := storage.(krot.KeyLister)
+		assert.False(t, ok)
+
+		_, ok = storage.(krot.KeyWatcher)
+		assert.False(t, ok)
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(storage))
+		assert.NoError(t, rotator.Rotate())
+
+		keys, err := rotator.Keys(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, rotator.RotationKeyCount())
+	})
+}
+
+func TestKeyWrapper(t *testing.T) {
+	ctx := context.Background()
+
+	kek := make([]byte, 32)
+	_, err := rand.Read(kek)
+	assert.NoError(t, err)
+
+	encoded := base64.StdEncoding.EncodeToString(kek)
+
+	path := filepath.Join(t.TempDir(), "kek.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(encoded+"\n"), 0o600))
+
+	t.Setenv("KROT_TEST_KEK", encoded)
+
+	newWrappers := map[string]func() (krot.KeyWrapper, error){
+		"AES":  func() (krot.KeyWrapper, error) { return krot.NewAESKeyWrapper(kek) },
+		"File": func() (krot.KeyWrapper, error) { return krot.NewFileKeyWrapper(path) },
+		"Env":  func() (krot.KeyWrapper, error) { return krot.NewEnvKeyWrapper("KROT_TEST_KEK") },
+	}
+
+	for name, newWrapper := range newWrappers {
+		t.Run(name, func(t *testing.T) {
+			wrapper, err := newWrapper()
+			assert.NoError(t, err)
+
+			wrapped, err := wrapper.WrapKey(ctx, []byte("data key"))
+			assert.NoError(t, err)
+			assert.NotContains(t, string(wrapped), "data key")
+
+			// Wrappers sharing a KEK can unwrap each other's keys.
+			other, err := krot.NewAESKeyWrapper(kek)
+			assert.NoError(t, err)
+
+			key, err := other.UnwrapKey(ctx, wrapped)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("data key"), key)
+
+			wrapped[len(wrapped)-1] ^= 1
+			_, err = wrapper.UnwrapKey(ctx, wrapped)
+			assert.ErrorIs(t, err, krot.ErrKeyDecryptionFailed)
+		})
+	}
+
+	t.Run("Should reject invalid KEKs", func(t *testing.T) {
+		_, err := krot.NewAESKeyWrapper([]byte("short"))
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+
+		_, err = krot.NewEnvKeyWrapper("KROT_TEST_MISSING_KEK")
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+
+		t.Setenv("KROT_TEST_KEK", "not base64")
+		_, err = krot.NewEnvKeyWrapper("KROT_TEST_KEK")
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+	})
+}
+
+func TestRotatorWithEncryptedKeyStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	storage := krot.NewEncryptedKeyStorage(krot.NewFileKeyStorage(path), krottest.NewKMS())
+
+	rotator := krot.New()
+	assert.NoError(t, rotator.SetStorage(storage))
+	assert.NoError(t, rotator.Rotate())
+
+	key, err := rotator.GetKey()
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), key.Value)
+}
@@ -0,0 +1,202 @@
+package krot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestRotatorEvents(t *testing.T) {
+	ctx := context.Background()
+
+	next := func(t *testing.T, events <-chan krot.RotatorEvent) krot.RotatorEvent {
+		t.Helper()
+
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no event received")
+			return nil
+		}
+	}
+
+	t.Run("Should report rotated keys", func(t *testing.T) {
+		rotator := krot.New()
+		events := rotator.Subscribe(ctx)
+
+		assert.NoError(t, rotator.Rotate())
+
+		first := next(t, events).(krot.KeysRotated)
+		assert.Len(t, first.Added, rotator.RotationKeyCount())
+		assert.Empty(t, first.Retired)
+
+		assert.NoError(t, rotator.Rotate())
+
+		second := next(t, events).(krot.KeysRotated)
+		assert.Len(t, second.Added, rotator.RotationKeyCount())
+		assert.NotContains(t, second.Added, first.Added[0])
+		assert.ElementsMatch(t, first.Added, second.Retired)
+	})
+
+	t.Run("Should report revoked keys", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+
+		events := rotator.Subscribe(ctx)
+		assert.NoError(t, rotator.Revoke(ctx, key.ID, "unknown"))
+		assert.Equal(t, krot.KeysRevoked{IDs: []string{key.ID}}, next(t, events))
+
+		// Nothing left to revoke.
+		assert.NoError(t, rotator.Revoke(ctx, key.ID))
+		assert.Empty(t, events)
+	})
+
+	t.Run("Should report keys retired after their last use", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationKeyCount = 2
+		settings.MaxKeyUses = 1
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		events := rotator.Subscribe(ctx)
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Equal(t, krot.KeysRotated{Retired: []string{key.ID}}, next(t, events))
+	})
+
+	t.Run("Should report failed rotations", func(t *testing.T) {
+		failure := errors.New("generator failure")
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetGenerator(krot.Untyped(krot.TypedKeyGeneratorFunc[string](func() (string, error) {
+			return "", failure
+		}))))
+
+		events := rotator.Subscribe(ctx)
+		assert.ErrorIs(t, rotator.Rotate(), failure)
+
+		event := next(t, events).(krot.RotationFailed)
+		assert.ErrorIs(t, event.Err, failure)
+	})
+
+	t.Run("Should report the keys loaded by followers", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+
+		writer := krot.New()
+		assert.NoError(t, writer.SetStorage(storage))
+		writerEvents := writer.Subscribe(ctx)
+
+		settings := krot.DefaultRotatorSettings()
+		settings.Follower = true
+
+		follower, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, follower.SetStorage(storage))
+		followerEvents := follower.Subscribe(ctx)
+
+		assert.NoError(t, writer.Rotate())
+		assert.NoError(t, follower.Rotate())
+
+		first := next(t, writerEvents).(krot.KeysRotated)
+		loaded := next(t, followerEvents).(krot.KeysRotated)
+		assert.ElementsMatch(t, first.Added, loaded.Added)
+		assert.Empty(t, loaded.Retired)
+
+		// Loading the same keys again is not a rotation.
+		assert.NoError(t, follower.Rotate())
+		assert.Empty(t, followerEvents)
+
+		assert.NoError(t, writer.Rotate())
+		assert.NoError(t, follower.Rotate())
+
+		second := next(t, writerEvents).(krot.KeysRotated)
+		followed := next(t, followerEvents).(krot.KeysRotated)
+		assert.ElementsMatch(t, second.Added, followed.Added)
+		assert.ElementsMatch(t, second.Retired, followed.Retired)
+	})
+
+	t.Run("Should report cleaned keys", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+		storage := krot.NewKeyStorageWithClock(clock)
+		assert.NoError(t, storage.Add(ctx,
+			&krot.Key{ID: "1", Value: "1", Expires: clock.Now().Add(-time.Minute)},
+			&krot.Key{ID: "2", Value: "2", Expires: clock.Now().Add(-time.Minute)},
+		))
+
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationInterval = 24 * time.Hour
+		settings.KeyExpiration = time.Hour
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetStorage(storage))
+
+		events := rotator.Subscribe(ctx)
+
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+		assert.IsType(t, krot.KeysRotated{}, next(t, events))
+
+		clock.BlockUntil(2)
+		clock.Advance(settings.KeyExpiration + time.Second)
+		assert.Equal(t, krot.KeysCleaned{Count: 2}, next(t, events))
+	})
+
+	t.Run("Should not report cleanings of storages that cannot count keys", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationInterval = 24 * time.Hour
+		settings.KeyExpiration = time.Hour
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetStorage(&unlistableStorage{krot.NewKeyStorageWithClock(clock)}))
+
+		events := rotator.Subscribe(ctx)
+
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+		assert.IsType(t, krot.KeysRotated{}, next(t, events))
+
+		clock.BlockUntil(2)
+		clock.Advance(settings.KeyExpiration + time.Second)
+		clock.BlockUntil(2)
+		assert.Empty(t, events)
+	})
+
+	t.Run("Should drop events for slow subscribers", func(t *testing.T) {
+		rotator := krot.New()
+
+		subscription, cancel := context.WithCancel(ctx)
+		events := rotator.Subscribe(subscription)
+
+		for i := 0; i < krot.RotatorEventBufferSize+10; i++ {
+			assert.NoError(t, rotator.Rotate())
+		}
+
+		assert.Len(t, events, krot.RotatorEventBufferSize)
+
+		cancel()
+
+		received := 0
+		for range events {
+			received++
+		}
+		assert.Equal(t, krot.RotatorEventBufferSize, received)
+	})
+}
@@ -0,0 +1,99 @@
+package krot_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+)
+
+func TestFileKeyStorage(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	storage := krot.NewFileKeyStorage(path)
+
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	assert.NoError(t, storage.Add(ctx,
+		&krot.Key{ID: "1", Value: "1", Expires: expires},
+		&krot.Key{ID: "2", Value: "2", Expires: expires, State: krot.KeyStateRetiring},
+		&krot.Key{ID: "3", Value: "3", Expires: time.Now().Add(-time.Minute)},
+	))
+
+	t.Run("Should persist keys across instances", func(t *testing.T) {
+		key, err := krot.NewFileKeyStorage(path).Get(ctx, "2")
+		assert.NoError(t, err)
+		assert.Equal(t, &krot.Key{ID: "2", Value: "2", Expires: expires, State: krot.KeyStateRetiring}, key)
+	})
+
+	t.Run("Should restrict the file permissions", func(t *testing.T) {
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("Should list non-expired keys", func(t *testing.T) {
+		keys, err := storage.(krot.KeyLister).List(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+	})
+
+	t.Run("Should clear expired keys", func(t *testing.T) {
+		assert.NoError(t, storage.ClearDeprecated(ctx))
+
+		_, err := storage.Get(ctx, "3")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+
+		_, err = storage.Get(ctx, "1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should delete keys", func(t *testing.T) {
+		assert.NoError(t, storage.Delete(ctx, "1"))
+
+		_, err := storage.Get(ctx, "1")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+
+	t.Run("Should not lose concurrent writes", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				// Each writer has its own instance, as separate processes would.
+				writer := krot.NewFileKeyStorage(path)
+				err := writer.Add(ctx, &krot.Key{ID: fmt.Sprintf("concurrent-%d", i), Value: "value", Expires: expires})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		keys, err := storage.(krot.KeyLister).List(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 21)
+	})
+
+	t.Run("Should erase keys", func(t *testing.T) {
+		assert.NoError(t, storage.Erase(ctx))
+
+		keys, err := storage.(krot.KeyLister).List(ctx, &krot.KeyListOptions{IncludeExpired: true})
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Should report a corrupted file", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+		_, err := storage.Get(ctx, "1")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+}
@@ -0,0 +1,123 @@
+package krot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+type unwatchableStorage struct {
+	krot.KeyStorage
+	krot.KeyLister
+}
+
+func TestRotatorFollower(t *testing.T) {
+	ctx := context.Background()
+
+	newFollower := func(t *testing.T, storage krot.KeyStorage, clock krot.Clock) *krot.Rotator {
+		settings := krot.DefaultRotatorSettings()
+		settings.AutoClearExpiredKeys = false
+		settings.Follower = true
+		settings.SyncInterval = time.Minute
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetStorage(storage))
+		return rotator
+	}
+
+	t.Run("Should never generate keys", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+		follower := newFollower(t, storage, nil)
+
+		assert.NoError(t, follower.Rotate())
+		assert.False(t, follower.Leader())
+
+		_, err := follower.GetKey()
+		assert.ErrorIs(t, err, krot.ErrNoKeysGenerated)
+
+		keys, err := storage.(krot.KeyLister).List(ctx, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Should load the writer's keys", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+
+		writer := krot.New()
+		assert.NoError(t, writer.SetStorage(storage))
+		assert.NoError(t, writer.Rotate())
+
+		follower := newFollower(t, storage, nil)
+		assert.NoError(t, follower.Rotate())
+
+		key, err := follower.GetKey()
+		assert.NoError(t, err)
+		assert.Contains(t, key.ID, writer.ID())
+	})
+
+	t.Run("Should sync on storage changes", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+
+		writer := krot.New()
+		assert.NoError(t, writer.SetStorage(storage))
+		assert.NoError(t, writer.Rotate())
+
+		follower := newFollower(t, storage, nil)
+		assert.NoError(t, follower.Start())
+		defer follower.Stop()
+
+		first, err := follower.GetKey()
+		assert.NoError(t, err)
+
+		assert.NoError(t, writer.Rotate())
+
+		assert.Eventually(t, func() bool {
+			key, err := follower.GetKey()
+			return err == nil && key.ID != first.ID && key.State == krot.KeyStateActive
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("Should sync on every interval", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+		storage := krot.NewKeyStorageWithClock(clock)
+
+		writer := krot.New()
+		assert.NoError(t, writer.SetStorage(storage))
+
+		follower := newFollower(t, &unwatchableStorage{storage, storage.(krot.KeyLister)}, clock)
+
+		syncs := make(chan struct{}, 2)
+		follower.AfterRotation(func(*krot.Rotator) { syncs <- struct{}{} })
+
+		assert.NoError(t, follower.Start())
+		defer follower.Stop()
+		<-syncs
+
+		_, err := follower.GetKey()
+		assert.ErrorIs(t, err, krot.ErrNoKeysGenerated)
+
+		assert.NoError(t, writer.Rotate())
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		<-syncs
+
+		key, err := follower.GetKey()
+		assert.NoError(t, err)
+		assert.Contains(t, key.ID, writer.ID())
+	})
+
+	t.Run("Should reject a negative sync interval", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.SyncInterval = -time.Second
+
+		_, err := krot.NewWithSettings(settings)
+		assert.ErrorIs(t, err, krot.ErrInvalidRotationSchedule)
+	})
+}
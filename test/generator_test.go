@@ -1,6 +1,12 @@
 package krot_test
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,5 +60,80 @@ func TestKeyGenerator(t *testing.T) {
 
 		assert.NotEmpty(t, key)
 	})
+}
+
+func TestAsymmetricKeyGenerator(t *testing.T) {
+	digest := sha256.Sum256([]byte("krot"))
+
+	t.Run("Should generate RSA key pairs", func(t *testing.T) {
+		generator := krot.NewRSAKeyGenerator(krot.RSAKeySize2048)
+
+		value, err := generator.Generate()
+		assert.NoError(t, err)
+
+		pair, ok := value.(*krot.KeyPair)
+		assert.True(t, ok)
+		assert.Equal(t, krot.AlgorithmRS256, pair.Algorithm)
+		assert.Equal(t, 2048, pair.Private.(*rsa.PrivateKey).N.BitLen())
+
+		signature, err := pair.Private.Sign(rand.Reader, digest[:], crypto.SHA256)
+		assert.NoError(t, err)
+		assert.NoError(t, rsa.VerifyPKCS1v15(pair.Public.(*rsa.PublicKey), crypto.SHA256, digest[:], signature))
+	})
+
+	t.Run("Should reject unsupported RSA key sizes", func(t *testing.T) {
+		generator := krot.NewRSAKeyGenerator(1024)
+
+		value, err := generator.Generate()
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+		assert.Nil(t, value)
+	})
+
+	t.Run("Should generate ECDSA key pairs", func(t *testing.T) {
+		curves := map[krot.ECDSACurve]string{
+			krot.ECDSACurveP256: krot.AlgorithmES256,
+			krot.ECDSACurveP384: krot.AlgorithmES384,
+			krot.ECDSACurveP521: krot.AlgorithmES512,
+		}
+
+		for curve, algorithm := range curves {
+			value, err := krot.NewECDSAKeyGenerator(curve).Generate()
+			assert.NoError(t, err)
 
+			pair := value.(*krot.KeyPair)
+			assert.Equal(t, algorithm, pair.Algorithm)
+
+			signature, err := pair.Private.Sign(rand.Reader, digest[:], nil)
+			assert.NoError(t, err)
+			assert.True(t, ecdsa.VerifyASN1(pair.Public.(*ecdsa.PublicKey), digest[:], signature))
+		}
+	})
+
+	t.Run("Should reject unsupported ECDSA curves", func(t *testing.T) {
+		value, err := krot.NewECDSAKeyGenerator(42).Generate()
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+		assert.Nil(t, value)
+	})
+
+	t.Run("Should generate Ed25519 key pairs", func(t *testing.T) {
+		value, err := krot.NewEd25519KeyGenerator().Generate()
+		assert.NoError(t, err)
+
+		pair := value.(*krot.KeyPair)
+		assert.Equal(t, krot.AlgorithmEdDSA, pair.Algorithm)
+
+		signature, err := pair.Private.Sign(rand.Reader, []byte("krot"), crypto.Hash(0))
+		assert.NoError(t, err)
+		assert.True(t, ed25519.Verify(pair.Public.(ed25519.PublicKey), []byte("krot"), signature))
+	})
+
+	t.Run("Should rotate asymmetric keys", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetGenerator(krot.NewEd25519KeyGenerator()))
+		assert.NoError(t, rotator.Rotate())
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.IsType(t, &krot.KeyPair{}, key.Value)
+	})
 }
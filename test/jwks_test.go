@@ -0,0 +1,82 @@
+package krot_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	rotator := krot.New()
+	assert.NoError(t, rotator.SetGenerator(krot.NewECDSAKeyGenerator(krot.ECDSACurveP256)))
+	assert.NoError(t, rotator.Start())
+	defer rotator.Stop()
+
+	handler := krot.NewJWKSHandler(rotator)
+
+	t.Run("Should publish every live key", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(response.Header().Get("Cache-Control"), "public, max-age="))
+
+		set := &krot.JWKSet{}
+		assert.NoError(t, json.Unmarshal(response.Body.Bytes(), set))
+		assert.Len(t, set.Keys, rotator.RotationKeyCount())
+
+		for _, jwk := range set.Keys {
+			assert.Equal(t, "EC", jwk.KeyType)
+			assert.Equal(t, "P-256", jwk.Curve)
+			assert.Equal(t, krot.AlgorithmES256, jwk.Algorithm)
+			assert.Equal(t, krot.JWKUseSignature, jwk.Use)
+
+			key, err := rotator.GetKeyByID(jwk.KeyID)
+			assert.NoError(t, err)
+			assert.NotNil(t, key)
+		}
+	})
+
+	t.Run("Should keep previous keys after rotation", func(t *testing.T) {
+		assert.NoError(t, rotator.Rotate())
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		set := &krot.JWKSet{}
+		assert.NoError(t, json.Unmarshal(response.Body.Bytes(), set))
+		assert.Len(t, set.Keys, 2*rotator.RotationKeyCount())
+	})
+
+	t.Run("Should reject other methods", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/.well-known/jwks.json", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+		assert.Equal(t, "GET, HEAD", response.Header().Get("Allow"))
+	})
+
+	t.Run("Should cap the cache lifetime", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		assert.Equal(t, "public, max-age=300", response.Header().Get("Cache-Control"))
+
+		response = httptest.NewRecorder()
+		krot.NewJWKSHandlerWithMaxAge(rotator, time.Minute).
+			ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		assert.Equal(t, "public, max-age=60", response.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Should not publish symmetric keys", func(t *testing.T) {
+		jwk, err := krot.NewJWK(&krot.Key{ID: "secret", Value: "deadbeef"})
+		assert.ErrorIs(t, err, krot.ErrUnsupportedKeyType)
+		assert.Nil(t, jwk)
+	})
+}
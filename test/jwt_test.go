@@ -0,0 +1,194 @@
+package krot_test
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	jwtgo "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	krotjwt "github.com/zhaori96/krot/jwt"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestJWT(t *testing.T) {
+	ctx := context.Background()
+
+	newRotator := func(generator krot.KeyGenerator) *krot.Rotator {
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetGenerator(generator))
+		assert.NoError(t, rotator.Rotate())
+		return rotator
+	}
+
+	keyID := func(token string) string {
+		parsed, _, err := jwtgo.NewParser().ParseUnverified(token, jwtgo.MapClaims{})
+		assert.NoError(t, err)
+		return parsed.Header[krotjwt.KeyIDHeader].(string)
+	}
+
+	t.Run("Should sign and verify tokens with every key type", func(t *testing.T) {
+		generators := map[string]krot.KeyGenerator{
+			"HS256": krot.NewKeyGenerator(krot.KeySize256),
+			"RS256": krot.NewRSAKeyGenerator(krot.RSAKeySize2048),
+			"ES256": krot.NewECDSAKeyGenerator(krot.ECDSACurveP256),
+			"ES512": krot.NewECDSAKeyGenerator(krot.ECDSACurveP521),
+			"EdDSA": krot.NewEd25519KeyGenerator(),
+		}
+
+		for algorithm, generator := range generators {
+			rotator := newRotator(generator)
+
+			token, err := krotjwt.NewSigner(rotator).Sign(krotjwt.MapClaims{"sub": "user"})
+			assert.NoError(t, err, algorithm)
+
+			parsed, _, err := jwtgo.NewParser().ParseUnverified(token, jwtgo.MapClaims{})
+			assert.NoError(t, err, algorithm)
+			assert.Equal(t, algorithm, parsed.Method.Alg())
+
+			_, err = rotator.GetKeyByID(keyID(token))
+			assert.NoError(t, err, algorithm)
+
+			claims, err := krotjwt.NewVerifier(rotator).Verify(token)
+			assert.NoError(t, err, algorithm)
+			assert.Equal(t, "user", claims["sub"], algorithm)
+		}
+	})
+
+	t.Run("Should verify tokens signed before a rotation", func(t *testing.T) {
+		rotator := newRotator(krot.NewEd25519KeyGenerator())
+
+		token, err := krotjwt.NewSigner(rotator).Sign(&krotjwt.RegisteredClaims{Subject: "user"})
+		assert.NoError(t, err)
+
+		assert.NoError(t, rotator.Rotate())
+
+		claims := &krotjwt.RegisteredClaims{}
+		assert.NoError(t, krotjwt.NewVerifier(rotator).VerifyClaims(ctx, token, claims))
+		assert.Equal(t, "user", claims.Subject)
+	})
+
+	t.Run("Should reject revoked and expired keys", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(storage))
+		assert.NoError(t, rotator.Rotate())
+
+		token, err := krotjwt.NewSigner(rotator).Sign(krotjwt.MapClaims{})
+		assert.NoError(t, err)
+
+		key, err := rotator.GetKeyByID(keyID(token))
+		assert.NoError(t, err)
+
+		expired := *key
+		expired.Expires = time.Now().Add(-time.Second)
+		assert.NoError(t, storage.Add(ctx, &expired))
+
+		_, err = krotjwt.NewVerifier(rotator).Verify(token)
+		assert.ErrorIs(t, err, krot.ErrKeyExpired)
+
+		assert.NoError(t, storage.Add(ctx, key))
+		assert.NoError(t, rotator.Revoke(ctx, key.ID))
+
+		_, err = krotjwt.NewVerifier(rotator).Verify(token)
+		assert.ErrorIs(t, err, krot.ErrKeyRevoked)
+	})
+
+	t.Run("Should reject tokens without a known key", func(t *testing.T) {
+		rotator := newRotator(krot.NewKeyGenerator(krot.KeySize256))
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+
+		unsigned, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, jwtgo.MapClaims{}).SignedString([]byte(key.Value.(string)))
+		assert.NoError(t, err)
+
+		_, err = krotjwt.NewVerifier(rotator).Verify(unsigned)
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+
+		token := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, jwtgo.MapClaims{})
+		token.Header[krotjwt.KeyIDHeader] = "unknown"
+		unknown, err := token.SignedString([]byte(key.Value.(string)))
+		assert.NoError(t, err)
+
+		_, err = krotjwt.NewVerifier(rotator).Verify(unknown)
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+
+	t.Run("Should reject tokens whose algorithm does not match their key", func(t *testing.T) {
+		rotator := newRotator(krot.NewKeyGenerator(krot.KeySize256))
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+
+		token := jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, jwtgo.MapClaims{})
+		token.Header[krotjwt.KeyIDHeader] = key.ID
+		signed, err := token.SignedString([]byte(key.Value.(string)))
+		assert.NoError(t, err)
+
+		_, err = krotjwt.NewVerifier(rotator).Verify(signed)
+		assert.ErrorIs(t, err, krot.ErrKeyTypeMismatch)
+	})
+
+	t.Run("Should reject tampered tokens and invalid claims", func(t *testing.T) {
+		rotator := newRotator(krot.NewEd25519KeyGenerator())
+		signer, verifier := krotjwt.NewSigner(rotator), krotjwt.NewVerifier(rotator)
+
+		token, err := signer.Sign(krotjwt.MapClaims{"sub": "user"})
+		assert.NoError(t, err)
+
+		parts := strings.Split(token, ".")
+		parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`))
+
+		_, err = verifier.Verify(strings.Join(parts, "."))
+		assert.ErrorIs(t, err, jwtgo.ErrTokenSignatureInvalid)
+
+		expired, err := signer.Sign(&krotjwt.RegisteredClaims{ExpiresAt: krotjwt.NewNumericDate(time.Now().Add(-time.Minute))})
+		assert.NoError(t, err)
+
+		_, err = verifier.Verify(expired)
+		assert.ErrorIs(t, err, jwtgo.ErrTokenExpired)
+	})
+
+	t.Run("Should check expirations against the rotator's clock", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.KeyExpiration = time.Hour
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		signer, verifier := krotjwt.NewSigner(rotator), krotjwt.NewVerifier(rotator)
+
+		token, err := signer.Sign(&krotjwt.RegisteredClaims{ExpiresAt: krotjwt.NewNumericDate(clock.Now().Add(time.Minute))})
+		assert.NoError(t, err)
+		assert.NoError(t, verifier.VerifyClaims(ctx, token, &krotjwt.RegisteredClaims{}))
+
+		clock.Advance(2 * time.Minute)
+		err = verifier.VerifyClaims(ctx, token, &krotjwt.RegisteredClaims{})
+		assert.ErrorIs(t, err, jwtgo.ErrTokenExpired)
+
+		unbounded, err := signer.Sign(krotjwt.MapClaims{})
+		assert.NoError(t, err)
+
+		clock.Advance(settings.RotationInterval + settings.KeyExpiration)
+		_, err = verifier.Verify(unbounded)
+		assert.ErrorIs(t, err, krot.ErrKeyExpired)
+	})
+
+	t.Run("Should reject keys that cannot sign tokens", func(t *testing.T) {
+		rotator := newRotator(krot.Untyped(krot.TypedKeyGeneratorFunc[int](func() (int, error) {
+			return 42, nil
+		})))
+
+		_, err := krotjwt.NewSigner(rotator).Sign(krotjwt.MapClaims{})
+		assert.ErrorIs(t, err, krot.ErrUnsupportedKeyType)
+	})
+}
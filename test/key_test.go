@@ -0,0 +1,54 @@
+package krot_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+)
+
+func TestKey(t *testing.T) {
+	t.Run("Should encode states by name", func(t *testing.T) {
+		data, err := json.Marshal(&krot.Key{ID: "1", Value: "1", State: krot.KeyStateRetiring})
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"state":"retiring"`)
+
+		key := &krot.Key{}
+		assert.NoError(t, json.Unmarshal(data, key))
+		assert.Equal(t, krot.KeyStateRetiring, key.State)
+	})
+
+	t.Run("Should decode keys without state as active", func(t *testing.T) {
+		key := &krot.Key{}
+		assert.NoError(t, json.Unmarshal([]byte(`{"id":"1","value":"1"}`), key))
+		assert.Equal(t, krot.KeyStateActive, key.State)
+	})
+
+	t.Run("Should reject unknown states", func(t *testing.T) {
+		key := &krot.Key{}
+		assert.ErrorIs(t, json.Unmarshal([]byte(`{"state":"lost"}`), key), krot.ErrInvalidArgument)
+	})
+
+	t.Run("Should tell signing and verification keys apart", func(t *testing.T) {
+		expires := time.Now().Add(time.Hour)
+
+		cases := map[krot.KeyState][2]bool{
+			krot.KeyStateActive:   {true, true},
+			krot.KeyStatePending:  {false, true},
+			krot.KeyStateRetiring: {false, true},
+			krot.KeyStateRevoked:  {false, false},
+		}
+
+		for state, expected := range cases {
+			key := &krot.Key{State: state, Expires: expires}
+			assert.Equal(t, expected[0], key.CanSign(), state.String())
+			assert.Equal(t, expected[1], key.CanVerify(), state.String())
+		}
+
+		expired := &krot.Key{Expires: time.Now().Add(-time.Hour)}
+		assert.False(t, expired.CanSign())
+		assert.False(t, expired.CanVerify())
+	})
+}
@@ -0,0 +1,207 @@
+package krot_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestRotationLock(t *testing.T) {
+	ctx := context.Background()
+
+	locks := map[string]func(t *testing.T, clock krot.Clock) (krot.RotationLock, krot.RotationLock){
+		"InMemory": func(t *testing.T, clock krot.Clock) (krot.RotationLock, krot.RotationLock) {
+			lock := krot.NewRotationLockWithClock(clock)
+			return lock, lock
+		},
+		"File": func(t *testing.T, clock krot.Clock) (krot.RotationLock, krot.RotationLock) {
+			path := filepath.Join(t.TempDir(), "rotation.lock")
+			return krot.NewFileRotationLockWithClock(path, clock), krot.NewFileRotationLockWithClock(path, clock)
+		},
+	}
+
+	for name, newLocks := range locks {
+		t.Run(name, func(t *testing.T) {
+			clock := krottest.NewClock(time.Now())
+			first, second := newLocks(t, clock)
+
+			t.Run("Should grant the lease to a single owner", func(t *testing.T) {
+				acquired, err := first.Acquire(ctx, "a", time.Hour)
+				assert.NoError(t, err)
+				assert.True(t, acquired)
+
+				acquired, err = second.Acquire(ctx, "b", time.Hour)
+				assert.NoError(t, err)
+				assert.False(t, acquired)
+
+				acquired, err = first.Acquire(ctx, "a", time.Hour)
+				assert.NoError(t, err)
+				assert.True(t, acquired)
+			})
+
+			t.Run("Should hand the lease over on release", func(t *testing.T) {
+				assert.NoError(t, second.Release(ctx, "b"))
+
+				acquired, err := second.Acquire(ctx, "b", time.Hour)
+				assert.NoError(t, err)
+				assert.False(t, acquired)
+
+				assert.NoError(t, first.Release(ctx, "a"))
+
+				acquired, err = second.Acquire(ctx, "b", time.Minute)
+				assert.NoError(t, err)
+				assert.True(t, acquired)
+			})
+
+			t.Run("Should hand the lease over on expiration", func(t *testing.T) {
+				acquired, err := first.Acquire(ctx, "a", time.Hour)
+				assert.NoError(t, err)
+				assert.False(t, acquired)
+
+				clock.Advance(time.Minute)
+
+				acquired, err = first.Acquire(ctx, "a", time.Hour)
+				assert.NoError(t, err)
+				assert.True(t, acquired)
+			})
+		})
+	}
+}
+
+func TestRotationRequester(t *testing.T) {
+	ctx := context.Background()
+
+	clock := krottest.NewClock(time.Now())
+	lock := krot.NewRotationLockWithClock(clock)
+	requester := lock.(krot.RotationRequester)
+
+	requests, err := requester.RotationRequests(ctx, "a")
+	assert.NoError(t, err)
+
+	others, err := requester.RotationRequests(ctx, "b")
+	assert.NoError(t, err)
+
+	t.Run("Should drop requests without a leader", func(t *testing.T) {
+		assert.NoError(t, requester.RequestRotation(ctx))
+		assert.Empty(t, requests)
+		assert.Empty(t, others)
+	})
+
+	t.Run("Should forward requests to the leader", func(t *testing.T) {
+		acquired, err := lock.Acquire(ctx, "a", time.Hour)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+
+		assert.NoError(t, requester.RequestRotation(ctx))
+		assert.NoError(t, requester.RequestRotation(ctx))
+		assert.Len(t, requests, 1)
+		assert.Empty(t, others)
+	})
+
+	t.Run("Should drop requests once the lease expires", func(t *testing.T) {
+		<-requests
+		clock.Advance(time.Hour)
+
+		assert.NoError(t, requester.RequestRotation(ctx))
+		assert.Empty(t, requests)
+	})
+
+	t.Run("Should close the channel with its context", func(t *testing.T) {
+		subscription, cancel := context.WithCancel(ctx)
+		requests, err := requester.RotationRequests(subscription, "a")
+		assert.NoError(t, err)
+
+		cancel()
+		_, open := <-requests
+		assert.False(t, open)
+	})
+}
+
+func TestRotatorCoordination(t *testing.T) {
+	ctx := context.Background()
+
+	clock := krottest.NewClock(time.Now())
+	storage := krot.NewKeyStorageWithClock(clock)
+	lock := krot.NewRotationLockWithClock(clock)
+
+	newRotator := func(t *testing.T) *krot.Rotator {
+		settings := krot.DefaultRotatorSettings()
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetStorage(storage))
+		assert.NoError(t, rotator.SetRotationLock(lock))
+		return rotator
+	}
+
+	leader, follower := newRotator(t), newRotator(t)
+
+	t.Run("Should let a single replica rotate", func(t *testing.T) {
+		assert.NoError(t, leader.Rotate())
+		assert.NoError(t, follower.Rotate())
+
+		assert.True(t, leader.Leader())
+		assert.False(t, follower.Leader())
+
+		keys, err := follower.Keys(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, leader.RotationKeyCount())
+
+		for i := 0; i < 10; i++ {
+			key, err := follower.GetKey()
+			assert.NoError(t, err)
+			assert.Contains(t, key.ID, leader.ID())
+		}
+	})
+
+	t.Run("Should follow the leader's rotations", func(t *testing.T) {
+		clock.Advance(time.Hour)
+		assert.NoError(t, leader.Rotate())
+
+		key, err := follower.GetKey()
+		assert.NoError(t, err)
+		assert.Equal(t, krot.KeyStateActive, key.State)
+
+		keys, err := follower.Keys(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2*leader.RotationKeyCount())
+	})
+
+	t.Run("Should take over when the leader stops", func(t *testing.T) {
+		assert.NoError(t, leader.Start())
+		leader.Stop()
+
+		assert.NoError(t, follower.Rotate())
+		assert.True(t, follower.Leader())
+		assert.False(t, leader.Leader())
+
+		key, err := follower.GetKey()
+		assert.NoError(t, err)
+		assert.Contains(t, key.ID, follower.ID())
+
+		keys, err := storage.(krot.KeyLister).List(ctx, &krot.KeyListOptions{States: []krot.KeyState{krot.KeyStateActive}})
+		assert.NoError(t, err)
+		assert.Len(t, keys, follower.RotationKeyCount())
+	})
+
+	t.Run("Should take over when the lease expires", func(t *testing.T) {
+		clock.Advance(2 * follower.RotationInterval())
+
+		assert.NoError(t, leader.Rotate())
+		assert.True(t, leader.Leader())
+	})
+
+	t.Run("Should require a KeyLister storage", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(&unlistableStorage{krot.NewKeyStorage()}))
+		assert.NoError(t, rotator.SetRotationLock(krot.NewRotationLock()))
+
+		assert.ErrorIs(t, rotator.Rotate(), krot.ErrUnsupportedStorage)
+	})
+}
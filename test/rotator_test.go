@@ -1,12 +1,481 @@
 package krot_test
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
 )
 
+// unlistableStorage hides the KeyLister implementation of the wrapped storage.
+type unlistableStorage struct {
+	krot.KeyStorage
+}
+
 // Should test github.com/zhaori96/krot/pkg/Rotator
 func TestRotator(t *testing.T) {
 	t.Run("Should rotate keys", func(t *testing.T) {
-		t.Skip("TODO")
+		clock := krottest.NewClock(time.Now())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.AutoClearExpiredKeys = false
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+
+		rotations := make(chan struct{}, 2)
+		rotator.AfterRotation(func(*krot.Rotator) { rotations <- struct{}{} })
+
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+		<-rotations
+
+		first, err := rotator.GetKey()
+		assert.NoError(t, err)
+
+		clock.BlockUntil(1)
+		clock.Advance(settings.RotationInterval - time.Second)
+		assert.Empty(t, rotations)
+
+		clock.Advance(time.Second)
+		<-rotations
+
+		second, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.NotEqual(t, first.ID, second.ID)
+
+		retired, err := rotator.GetKeyByID(first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, krot.KeyStateRetiring, retired.State)
+	})
+
+	t.Run("Should push the schedule back after manual rotations", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.AutoClearExpiredKeys = false
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+
+		rotations := make(chan struct{}, 3)
+		rotator.AfterRotation(func(*krot.Rotator) { rotations <- struct{}{} })
+
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+		<-rotations
+
+		clock.BlockUntil(1)
+		clock.Advance(settings.RotationInterval / 2)
+
+		assert.NoError(t, rotator.Rotate())
+		<-rotations
+
+		// The timer set for the original schedule fires, but the next rotation
+		// is now due half an interval later.
+		clock.Advance(settings.RotationInterval / 2)
+		clock.BlockUntil(1)
+		assert.Empty(t, rotations)
+
+		clock.Advance(settings.RotationInterval / 2)
+		<-rotations
+	})
+
+	t.Run("Should retry failed rotations", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.AutoClearExpiredKeys = false
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+
+		var failing atomic.Bool
+		failure := errors.New("generator failure")
+		assert.NoError(t, rotator.SetGenerator(krot.Untyped(krot.TypedKeyGeneratorFunc[string](func() (string, error) {
+			if failing.Load() {
+				return "", failure
+			}
+			return "secret", nil
+		}))))
+
+		events := rotator.Subscribe(context.Background())
+
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+		assert.IsType(t, krot.KeysRotated{}, <-events)
+
+		failing.Store(true)
+		clock.BlockUntil(1)
+		clock.Advance(settings.RotationInterval)
+
+		event := (<-events).(krot.RotationFailed)
+		assert.ErrorIs(t, event.Err, failure)
+
+		// Failures keep being retried, further and further apart.
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		assert.IsType(t, krot.RotationFailed{}, <-events)
+
+		failing.Store(false)
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		assert.Empty(t, events)
+
+		clock.Advance(time.Second)
+		assert.IsType(t, krot.KeysRotated{}, <-events)
+		assert.Equal(t, krot.RotatorStatusStarted, rotator.Status())
+	})
+
+	t.Run("Should clear expired keys", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationInterval = 24 * time.Hour
+		settings.KeyExpiration = time.Hour
+		settings.ExtendExpiration = false
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.False(t, key.ExpiredAt(clock.Now()))
+
+		clock.BlockUntil(2)
+		clock.Advance(settings.KeyExpiration + time.Second)
+		clock.BlockUntil(2)
+
+		_, err = rotator.GetKeyByID(key.ID)
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+}
+
+func TestRotatorKeys(t *testing.T) {
+	t.Run("Should list keys from a KeyLister storage", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+		assert.NoError(t, rotator.Rotate())
+
+		keys, err := rotator.Keys(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2*rotator.RotationKeyCount())
+	})
+
+	t.Run("Should list generated keys from other storages", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(&unlistableStorage{storage}))
+		assert.NoError(t, rotator.Rotate())
+
+		keys, err := rotator.Keys(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, keys, rotator.RotationKeyCount())
+
+		assert.NoError(t, storage.Delete(context.Background(), keys[0].ID))
+
+		keys, err = rotator.Keys(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, keys, rotator.RotationKeyCount()-1)
+	})
+
+	t.Run("Should forget expired keys on rotation", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+		storage := &contextRecordingStorage{KeyStorage: krot.NewKeyStorageWithClock(clock)}
+
+		settings := krot.DefaultRotatorSettings()
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetStorage(storage))
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, rotator.Rotate())
+			clock.Advance(settings.RotationInterval + settings.KeyExpiration + time.Second)
+		}
+		assert.NoError(t, rotator.Rotate())
+
+		storage.values = nil
+		keys, err := rotator.Keys(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, keys, settings.RotationKeyCount)
+
+		// Only the keys that have not expired are looked up.
+		assert.Len(t, storage.values, settings.RotationKeyCount)
+	})
+}
+
+type contextKey struct{}
+
+// contextRecordingStorage records the values found under contextKey in the
+// contexts passed to the wrapped storage.
+type contextRecordingStorage struct {
+	krot.KeyStorage
+	values []any
+}
+
+func (s *contextRecordingStorage) Get(ctx context.Context, id string) (*krot.Key, error) {
+	s.values = append(s.values, ctx.Value(contextKey{}))
+	return s.KeyStorage.Get(ctx, id)
+}
+
+func (s *contextRecordingStorage) Add(ctx context.Context, keys ...*krot.Key) error {
+	s.values = append(s.values, ctx.Value(contextKey{}))
+	return s.KeyStorage.Add(ctx, keys...)
+}
+
+func TestRotatorContext(t *testing.T) {
+	t.Run("Should pass the context to the storage", func(t *testing.T) {
+		storage := &contextRecordingStorage{KeyStorage: krot.NewKeyStorage()}
+		ctx := context.WithValue(context.Background(), contextKey{}, "request")
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(storage))
+		assert.NoError(t, rotator.RotateContext(ctx))
+
+		key, err := rotator.GetKeyContext(ctx)
+		assert.NoError(t, err)
+
+		_, err = rotator.GetKeyByIDContext(ctx, key.ID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []any{"request", "request", "request"}, storage.values)
+	})
+
+	t.Run("Should not reach the storage with a done context", func(t *testing.T) {
+		storage := &contextRecordingStorage{KeyStorage: krot.NewKeyStorage()}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(storage))
+
+		hookCalled := false
+		rotator.BeforeRotation(func(*krot.Rotator) { hookCalled = true })
+
+		assert.ErrorIs(t, rotator.RotateContext(ctx), context.Canceled)
+		assert.ErrorIs(t, rotator.StartContext(ctx), context.Canceled)
+		assert.Equal(t, krot.RotatorStatusStopped, rotator.Status())
+
+		_, err := rotator.GetKeyContext(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = rotator.GetKeyByIDContext(ctx, "id")
+		assert.ErrorIs(t, err, context.Canceled)
+
+		assert.False(t, hookCalled)
+		assert.Empty(t, storage.values)
+	})
+
+	t.Run("Should keep running after the start context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.StartContext(ctx))
+		defer rotator.Stop()
+		cancel()
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.NotNil(t, key)
+	})
+}
+
+func TestRotatorKeyLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	keysByState := func(t *testing.T, rotator *krot.Rotator) map[krot.KeyState][]*krot.Key {
+		keys, err := rotator.Keys(ctx)
+		assert.NoError(t, err)
+
+		states := map[krot.KeyState][]*krot.Key{}
+		for _, key := range keys {
+			states[key.State] = append(states[key.State], key)
+		}
+		return states
+	}
+
+	t.Run("Should retire active keys on rotation", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+		first := keysByState(t, rotator)[krot.KeyStateActive]
+
+		assert.NoError(t, rotator.Rotate())
+		states := keysByState(t, rotator)
+
+		assert.Len(t, states[krot.KeyStateActive], rotator.RotationKeyCount())
+		assert.Len(t, states[krot.KeyStateRetiring], rotator.RotationKeyCount())
+		assert.Empty(t, states[krot.KeyStatePending])
+
+		for _, key := range states[krot.KeyStateRetiring] {
+			assert.False(t, key.RetiredAt.IsZero())
+			assert.False(t, key.CanSign())
+			assert.True(t, key.CanVerify())
+		}
+
+		for _, key := range first {
+			retired, err := rotator.GetKeyByID(key.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, krot.KeyStateRetiring, retired.State)
+		}
+
+		for i := 0; i < 20; i++ {
+			key, err := rotator.GetKey()
+			assert.NoError(t, err)
+			assert.Equal(t, krot.KeyStateActive, key.State)
+		}
+	})
+
+	t.Run("Should pre-publish pending keys", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.PrePublishKeys = true
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		states := keysByState(t, rotator)
+		assert.Len(t, states[krot.KeyStateActive], rotator.RotationKeyCount())
+		assert.Len(t, states[krot.KeyStatePending], rotator.RotationKeyCount())
+
+		pending := states[krot.KeyStatePending]
+		for _, key := range pending {
+			assert.False(t, key.CanSign())
+			assert.True(t, key.CanVerify())
+			assert.True(t, key.Expires.After(states[krot.KeyStateActive][0].Expires))
+		}
+
+		assert.NoError(t, rotator.Rotate())
+		states = keysByState(t, rotator)
+
+		assert.ElementsMatch(t, pending, withState(states[krot.KeyStateActive], krot.KeyStatePending))
+		assert.Len(t, states[krot.KeyStatePending], rotator.RotationKeyCount())
+		assert.Len(t, states[krot.KeyStateRetiring], rotator.RotationKeyCount())
+	})
+
+	t.Run("Should skip keys revoked in the storage", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+
+		rotator := krot.New()
+		assert.NoError(t, rotator.SetStorage(storage))
+		assert.NoError(t, rotator.Rotate())
+
+		active := keysByState(t, rotator)[krot.KeyStateActive]
+		for _, key := range active[1:] {
+			revoked := *key
+			revoked.State = krot.KeyStateRevoked
+			assert.NoError(t, storage.Add(ctx, &revoked))
+		}
+
+		for i := 0; i < 10; i++ {
+			key, err := rotator.GetKey()
+			assert.NoError(t, err)
+			assert.Equal(t, active[0].ID, key.ID)
+		}
+
+		key, err := rotator.GetKeyByID(active[1].ID)
+		assert.ErrorIs(t, err, krot.ErrKeyRevoked)
+		assert.Nil(t, key)
+	})
+}
+
+// withState returns copies of the keys moved back to the given state, without
+// their activation time, so they can be compared to their earlier versions.
+func withState(keys []*krot.Key, state krot.KeyState) []*krot.Key {
+	copies := make([]*krot.Key, len(keys))
+	for i, key := range keys {
+		copied := *key
+		copied.State = state
+		copied.ActivatedAt = time.Time{}
+		copies[i] = &copied
+	}
+	return copies
+}
+
+func TestRotatorRevocation(t *testing.T) {
+	ctx := context.Background()
+
+	activeIDs := func(t *testing.T, rotator *krot.Rotator) []string {
+		keys, err := rotator.Keys(ctx)
+		assert.NoError(t, err)
+
+		ids := []string{}
+		for _, key := range keys {
+			if key.State == krot.KeyStateActive {
+				ids = append(ids, key.ID)
+			}
+		}
+		return ids
+	}
+
+	t.Run("Should revoke keys", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+
+		ids := activeIDs(t, rotator)
+		assert.NoError(t, rotator.Revoke(ctx, ids[0], "unknown"))
+
+		key, err := rotator.GetKeyByID(ids[0])
+		assert.ErrorIs(t, err, krot.ErrKeyRevoked)
+		assert.Nil(t, key)
+
+		for i := 0; i < 20; i++ {
+			key, err := rotator.GetKey()
+			assert.NoError(t, err)
+			assert.NotEqual(t, ids[0], key.ID)
+		}
+	})
+
+	t.Run("Should revoke all keys", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+		assert.NoError(t, rotator.Rotate())
+
+		keys, err := rotator.Keys(ctx)
+		assert.NoError(t, err)
+
+		assert.NoError(t, rotator.RevokeAll(ctx))
+
+		for _, key := range keys {
+			_, err := rotator.GetKeyByID(key.ID)
+			assert.ErrorIs(t, err, krot.ErrKeyRevoked)
+		}
+
+		key, err := rotator.GetKey()
+		assert.ErrorIs(t, err, krot.ErrNoKeysGenerated)
+		assert.Nil(t, key)
+	})
+
+	t.Run("Should rotate on revoke", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.RotateOnRevoke = true
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		revoked := activeIDs(t, rotator)
+		assert.NoError(t, rotator.RevokeAll(ctx))
+
+		active := activeIDs(t, rotator)
+		assert.Len(t, active, rotator.RotationKeyCount())
+		assert.NotContains(t, active, revoked[0])
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Contains(t, active, key.ID)
 	})
 }
@@ -0,0 +1,159 @@
+package krot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestRotationSchedule(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339, value)
+		assert.NoError(t, err)
+		return parsed
+	}
+
+	t.Run("Should rotate every interval", func(t *testing.T) {
+		schedule := krot.NewIntervalSchedule(90 * time.Minute)
+		assert.Equal(t, at("2024-01-01T04:17:00Z"), schedule.Next(at("2024-01-01T02:47:00Z")))
+	})
+
+	t.Run("Should not rotate on non-positive intervals", func(t *testing.T) {
+		for _, interval := range []time.Duration{0, -time.Hour} {
+			assert.True(t, krot.NewIntervalSchedule(interval).Next(at("2024-01-01T02:47:00Z")).IsZero(), interval)
+			assert.True(t, krot.NewAlignedSchedule(interval, 0).Next(at("2024-01-01T02:47:00Z")).IsZero(), interval)
+		}
+
+		now := at("2024-01-01T02:00:00Z")
+
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationSchedule = krot.NewIntervalSchedule(0)
+		settings.Clock = krottest.NewClock(now)
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		// The rotation is scheduled after the RotationInterval instead.
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Equal(t, now.Add(settings.RotationInterval+settings.KeyExpiration), key.Expires)
+	})
+
+	t.Run("Should rotate at calendar aligned times", func(t *testing.T) {
+		daily := krot.NewAlignedSchedule(24*time.Hour, 3*time.Hour)
+		assert.Equal(t, at("2024-01-01T03:00:00Z"), daily.Next(at("2024-01-01T02:47:00Z")))
+		assert.Equal(t, at("2024-01-02T03:00:00Z"), daily.Next(at("2024-01-01T03:00:00Z")))
+
+		quarterly := krot.NewAlignedSchedule(6*time.Hour, 0)
+		assert.Equal(t, at("2024-01-01T06:00:00Z"), quarterly.Next(at("2024-01-01T02:47:00Z")))
+		assert.Equal(t, at("2024-01-02T00:00:00Z"), quarterly.Next(at("2024-01-01T18:00:00Z")))
+	})
+
+	t.Run("Should rotate at cron times", func(t *testing.T) {
+		cases := []struct {
+			expression string
+			after      string
+			next       string
+		}{
+			{"0 3 * * *", "2024-01-01T02:47:00Z", "2024-01-01T03:00:00Z"},
+			{"0 3 * * *", "2024-01-01T03:00:00Z", "2024-01-02T03:00:00Z"},
+			{"*/15 * * * *", "2024-01-01T02:47:10Z", "2024-01-01T03:00:00Z"},
+			{"5-10/5 12 * * *", "2024-01-01T12:06:00Z", "2024-01-01T12:10:00Z"},
+			{"0 0 1,15 * *", "2024-01-02T00:00:00Z", "2024-01-15T00:00:00Z"},
+			{"0 9 * * mon-fri", "2024-01-05T10:00:00Z", "2024-01-08T09:00:00Z"},
+			{"0 0 * feb 7", "2024-01-01T00:00:00Z", "2024-02-04T00:00:00Z"},
+			{"0 0 13 * 5", "2024-01-01T00:00:00Z", "2024-01-05T00:00:00Z"},
+			{"0 0 29 2 *", "2024-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+			{"@monthly", "2024-01-31T23:59:00Z", "2024-02-01T00:00:00Z"},
+		}
+
+		for _, c := range cases {
+			schedule, err := krot.NewCronSchedule(c.expression, nil)
+			assert.NoError(t, err, c.expression)
+			assert.Equal(t, at(c.next), schedule.Next(at(c.after)), c.expression)
+		}
+	})
+
+	t.Run("Should evaluate cron expressions in a location", func(t *testing.T) {
+		location := time.FixedZone("UTC-3", -3*60*60)
+
+		schedule, err := krot.NewCronSchedule("0 3 * * *", location)
+		assert.NoError(t, err)
+		assert.True(t, at("2024-01-01T06:00:00Z").Equal(schedule.Next(at("2024-01-01T02:47:00Z"))))
+	})
+
+	t.Run("Should reject invalid cron expressions", func(t *testing.T) {
+		expressions := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *", "0 0 30 2 *"}
+
+		for _, expression := range expressions {
+			schedule, err := krot.NewCronSchedule(expression, nil)
+			assert.ErrorIs(t, err, krot.ErrInvalidRotationSchedule, expression)
+			assert.Nil(t, schedule, expression)
+		}
+	})
+
+	t.Run("Should rotate following the schedule", func(t *testing.T) {
+		clock := krottest.NewClock(at("2024-01-01T02:00:00Z"))
+
+		schedule, err := krot.NewCronSchedule("0 3 * * *", nil)
+		assert.NoError(t, err)
+
+		settings := krot.DefaultRotatorSettings()
+		settings.AutoClearExpiredKeys = false
+		settings.RotationSchedule = schedule
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+
+		rotations := make(chan struct{}, 2)
+		rotator.AfterRotation(func(*krot.Rotator) { rotations <- struct{}{} })
+
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+		<-rotations
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Equal(t, at("2024-01-01T03:00:00Z").Add(settings.KeyExpiration), key.Expires)
+
+		clock.BlockUntil(1)
+		clock.Advance(59 * time.Minute)
+		assert.Empty(t, rotations)
+
+		clock.Advance(time.Minute)
+		<-rotations
+
+		key, err = rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Equal(t, at("2024-01-02T03:00:00Z").Add(settings.KeyExpiration), key.Expires)
+	})
+
+	t.Run("Should add jitter to rotations", func(t *testing.T) {
+		now := at("2024-01-01T02:00:00Z")
+
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationJitter = time.Hour
+		settings.Clock = krottest.NewClock(now)
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.RotateContext(context.Background()))
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+
+		earliest := now.Add(settings.KeyExpiration + settings.RotationInterval)
+		assert.False(t, key.Expires.Before(earliest))
+		assert.True(t, key.Expires.Before(earliest.Add(settings.RotationJitter)))
+
+		settings.RotationJitter = -time.Second
+		assert.ErrorIs(t, settings.Validate(), krot.ErrInvalidRotationSchedule)
+	})
+}
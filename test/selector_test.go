@@ -0,0 +1,359 @@
+package krot_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+)
+
+// lastKeySelector always selects the last of its IDs.
+type lastKeySelector struct {
+	ids []string
+}
+
+func (s *lastKeySelector) Set(ids ...string) {
+	s.ids = ids
+}
+
+func (s *lastKeySelector) Select() (string, error) {
+	if len(s.ids) == 0 {
+		return "", krot.ErrNoKeysGenerated
+	}
+
+	return s.ids[len(s.ids)-1], nil
+}
+
+func TestKeySelector(t *testing.T) {
+	ids := []string{"1", "2", "3", "4", "5", "6", "7"}
+
+	selectN := func(t *testing.T, selector krot.KeySelector, n int) []string {
+		selected := make([]string, n)
+		for i := range selected {
+			id, err := selector.Select()
+			assert.NoError(t, err)
+			selected[i] = id
+		}
+
+		return selected
+	}
+
+	t.Run("Should return ErrNoKeysGenerated without IDs", func(t *testing.T) {
+		for mode := krot.AutoKeyProvidingMode; mode <= krot.NonRepeatingCyclicKeyProvidingMode; mode++ {
+			selector, err := krot.NewKeySelector(mode)
+			assert.NoError(t, err)
+
+			_, err = selector.Select()
+			assert.ErrorIs(t, err, krot.ErrNoKeysGenerated)
+		}
+	})
+
+	t.Run("Should reject unknown modes", func(t *testing.T) {
+		_, err := krot.NewKeySelector(krot.NonRepeatingCyclicKeyProvidingMode + 1)
+		assert.ErrorIs(t, err, krot.ErrInvalidKeyProvidingMode)
+	})
+
+	t.Run("Should cycle through the IDs in order", func(t *testing.T) {
+		selector := krot.NewCyclicKeySelector()
+		selector.Set(ids...)
+
+		selected := selectN(t, selector, 2*len(ids))
+		assert.Equal(t, ids, selected[:len(ids)])
+		assert.Equal(t, ids, selected[len(ids):])
+	})
+
+	t.Run("Should never select the same ID twice in a row", func(t *testing.T) {
+		for _, selector := range []krot.KeySelector{
+			krot.NewNonRepeatingKeySelector(),
+			krot.NewNonRepeatingCyclicKeySelector(),
+		} {
+			selector.Set(ids[:2]...)
+
+			selected := selectN(t, selector, 100)
+			for i := 1; i < len(selected); i++ {
+				assert.NotEqual(t, selected[i-1], selected[i])
+			}
+		}
+	})
+
+	t.Run("Should select every ID once per cycle", func(t *testing.T) {
+		selector := krot.NewNonRepeatingCyclicKeySelector()
+		selector.Set(ids...)
+
+		for cycle := 0; cycle < 10; cycle++ {
+			assert.ElementsMatch(t, ids, selectN(t, selector, len(ids)))
+		}
+	})
+
+	t.Run("Should only select the given IDs", func(t *testing.T) {
+		for mode := krot.AutoKeyProvidingMode; mode <= krot.NonRepeatingCyclicKeyProvidingMode; mode++ {
+			selector, err := krot.NewKeySelector(mode)
+			assert.NoError(t, err)
+
+			selector.Set(ids...)
+			selector.Set(ids[:3]...)
+
+			for _, id := range selectN(t, selector, 30) {
+				assert.Contains(t, ids[:3], id)
+			}
+		}
+	})
+
+	t.Run("Should be safe for concurrent use", func(t *testing.T) {
+		for mode := krot.AutoKeyProvidingMode; mode <= krot.NonRepeatingCyclicKeyProvidingMode; mode++ {
+			provider := krot.NewKeyIDProvider(mode, ids...)
+
+			var wg sync.WaitGroup
+			for worker := 0; worker < 8; worker++ {
+				wg.Add(1)
+				go func(worker int) {
+					defer wg.Done()
+
+					for i := 0; i < 100; i++ {
+						if worker == 0 && i%10 == 0 {
+							provider.Set(ids[:len(ids)-i%3]...)
+						}
+
+						id, err := provider.Get()
+						assert.NoError(t, err)
+						assert.Contains(t, ids, id)
+					}
+				}(worker)
+			}
+			wg.Wait()
+		}
+	})
+
+	t.Run("Should cycle through the IDs under concurrent use", func(t *testing.T) {
+		selector := krot.NewCyclicKeySelector()
+		selector.Set(ids...)
+
+		var mu sync.Mutex
+		counts := map[string]int{}
+
+		var wg sync.WaitGroup
+		for worker := 0; worker < 8; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for _, id := range selectN(t, selector, 10*len(ids)) {
+					mu.Lock()
+					counts[id]++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		for _, id := range ids {
+			assert.Equal(t, 80, counts[id], id)
+		}
+	})
+
+	t.Run("Should reproduce the selections of a seeded source", func(t *testing.T) {
+		for _, mode := range []krot.KeyProvidingMode{
+			krot.RandomKeyProvidingMode,
+			krot.NonRepeatingKeyProvidingMode,
+			krot.NonRepeatingCyclicKeyProvidingMode,
+		} {
+			sequences := make([][]string, 2)
+			for i := range sequences {
+				selector, err := krot.NewKeySelectorWithRandom(mode, krot.NewSeededRandomSource(42))
+				assert.NoError(t, err)
+
+				selector.Set(ids...)
+				sequences[i] = selectN(t, selector, 50)
+			}
+
+			assert.Equal(t, sequences[0], sequences[1])
+		}
+
+		_, err := krot.NewKeySelectorWithRandom(krot.RandomKeyProvidingMode, nil)
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+	})
+
+	t.Run("Should draw uniformly from the crypto source", func(t *testing.T) {
+		random := krot.NewCryptoRandomSource()
+
+		counts := make([]int, 3)
+		for i := 0; i < 3000; i++ {
+			counts[random.Intn(len(counts))]++
+		}
+
+		for _, count := range counts {
+			assert.InDelta(t, 1000, count, 150)
+		}
+	})
+
+	t.Run("Should follow the KeyProvidingMode setting", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationKeyCount = 3
+		settings.KeyProvidingMode = krot.CyclicKeyProvidingMode
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		selected := make([]string, 9)
+		for i := range selected {
+			selected[i], err = rotator.GetKeyID()
+			assert.NoError(t, err)
+		}
+
+		assert.Len(t, map[string]bool{selected[0]: true, selected[1]: true, selected[2]: true}, 3)
+		assert.Equal(t, selected[:3], selected[3:6])
+		assert.Equal(t, selected[:3], selected[6:])
+	})
+
+	t.Run("Should consult custom selectors", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+
+		selector := &lastKeySelector{}
+		assert.NoError(t, rotator.SetKeySelector(selector))
+		assert.Len(t, selector.ids, rotator.RotationKeyCount())
+
+		// Settings no longer replace the custom selector.
+		assert.NoError(t, rotator.SetSettings(krot.DefaultRotatorSettings()))
+		assert.NoError(t, rotator.Rotate())
+
+		for i := 0; i < 10; i++ {
+			key, err := rotator.GetKey()
+			assert.NoError(t, err)
+			assert.Equal(t, selector.ids[len(selector.ids)-1], key.ID)
+		}
+
+		assert.ErrorIs(t, rotator.SetKeySelector(nil), krot.ErrInvalidArgument)
+	})
+}
+
+func TestGetKeyFor(t *testing.T) {
+	ctx := context.Background()
+
+	subjects := make([]string, 1000)
+	for i := range subjects {
+		subjects[i] = fmt.Sprintf("user-%d", i)
+	}
+
+	keysFor := func(t *testing.T, rotator *krot.Rotator) map[string]string {
+		keys := make(map[string]string, len(subjects))
+		for _, subject := range subjects {
+			key, err := rotator.GetKeyFor(subject)
+			assert.NoError(t, err)
+			keys[subject] = key.ID
+		}
+
+		return keys
+	}
+
+	t.Run("Should return ErrNoKeysGenerated before the first rotation", func(t *testing.T) {
+		_, err := krot.New().GetKeyFor("user")
+		assert.ErrorIs(t, err, krot.ErrNoKeysGenerated)
+	})
+
+	t.Run("Should keep giving a subject the same key", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+
+		keys := keysFor(t, rotator)
+		assert.Equal(t, keys, keysFor(t, rotator))
+	})
+
+	t.Run("Should spread subjects over the keys", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+
+		counts := map[string]int{}
+		for _, id := range keysFor(t, rotator) {
+			counts[id]++
+		}
+
+		assert.Len(t, counts, rotator.RotationKeyCount())
+		for id, count := range counts {
+			// 200 subjects per key on average.
+			assert.Greater(t, count, 100, id)
+		}
+	})
+
+	t.Run("Should only remap the subjects of removed keys", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+
+		before := keysFor(t, rotator)
+
+		revoked := before[subjects[0]]
+		assert.NoError(t, rotator.Revoke(ctx, revoked))
+
+		for subject, id := range keysFor(t, rotator) {
+			assert.NotEqual(t, revoked, id)
+			if before[subject] != revoked {
+				assert.Equal(t, before[subject], id, subject)
+			}
+		}
+	})
+
+	t.Run("Should give new keys after a rotation", func(t *testing.T) {
+		rotator := krot.New()
+		assert.NoError(t, rotator.Rotate())
+
+		before, err := rotator.GetKeyFor("user")
+		assert.NoError(t, err)
+
+		assert.NoError(t, rotator.Rotate())
+
+		after, err := rotator.GetKeyFor("user")
+		assert.NoError(t, err)
+		assert.NotEqual(t, before.ID, after.ID)
+	})
+}
+
+func BenchmarkKeySelector(b *testing.B) {
+	ids := []string{"1", "2", "3", "4", "5", "6", "7", "8"}
+
+	modes := []struct {
+		name string
+		mode krot.KeyProvidingMode
+	}{
+		{"Auto", krot.AutoKeyProvidingMode},
+		{"Random", krot.RandomKeyProvidingMode},
+		{"NonRepeating", krot.NonRepeatingKeyProvidingMode},
+		{"Cyclic", krot.CyclicKeyProvidingMode},
+		{"NonRepeatingCyclic", krot.NonRepeatingCyclicKeyProvidingMode},
+	}
+
+	sources := []struct {
+		name   string
+		random func() krot.RandomSource
+	}{
+		{"Crypto", krot.NewCryptoRandomSource},
+		{"Seeded", func() krot.RandomSource { return krot.NewSeededRandomSource(1) }},
+	}
+
+	for _, mode := range modes {
+		for _, source := range sources {
+			b.Run(mode.name+"/"+source.name, func(b *testing.B) {
+				selector, err := krot.NewKeySelectorWithRandom(mode.mode, source.random())
+				if err != nil {
+					b.Fatal(err)
+				}
+				selector.Set(ids...)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if _, err := selector.Select(); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+	}
+}
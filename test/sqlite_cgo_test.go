@@ -0,0 +1,24 @@
+//go:build cgo
+
+package krot_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"github.com/zhaori96/krot"
+)
+
+// newSQLiteDB returns a migrated SQLite database in a temporary directory.
+func newSQLiteDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "krot.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, krot.MigrateSQLKeyStorage(context.Background(), db, krot.SQLDialectSQLite))
+	return db
+}
@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package krot_test
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// newSQLiteDB skips the test, as the SQLite driver requires cgo.
+func newSQLiteDB(t *testing.T) *sql.DB {
+	t.Skip("SQLite tests require cgo")
+	return nil
+}
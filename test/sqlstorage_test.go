@@ -0,0 +1,138 @@
+package krot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestSQLKeyStorage(t *testing.T) {
+	ctx := context.Background()
+
+	db := newSQLiteDB(t)
+	storage := krot.NewSQLKeyStorage(db, krot.SQLDialectSQLite)
+
+	now := time.Now().Truncate(time.Microsecond)
+	expires := now.Add(time.Hour)
+
+	assert.NoError(t, storage.Add(ctx,
+		&krot.Key{ID: "1", Value: "1", Expires: expires, CreatedAt: now},
+		&krot.Key{ID: "2", Value: "2", Expires: expires, State: krot.KeyStateRetiring, RetiredAt: now},
+		&krot.Key{ID: "3", Value: "3", Expires: now.Add(-time.Minute)},
+	))
+
+	t.Run("Should run migrations again", func(t *testing.T) {
+		assert.NoError(t, krot.MigrateSQLKeyStorage(ctx, db, krot.SQLDialectSQLite))
+	})
+
+	t.Run("Should get keys", func(t *testing.T) {
+		key, err := storage.Get(ctx, "2")
+		require.NoError(t, err)
+		assert.Equal(t, "2", key.ID)
+		assert.Equal(t, "2", key.Value)
+		assert.Equal(t, krot.KeyStateRetiring, key.State)
+		assert.True(t, key.Expires.Equal(expires))
+		assert.True(t, key.RetiredAt.Equal(now))
+		assert.True(t, key.CreatedAt.IsZero())
+
+		_, err = storage.Get(ctx, "unknown")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+
+	t.Run("Should replace existing keys", func(t *testing.T) {
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "1", Value: "1", Expires: expires, State: krot.KeyStateRevoked}))
+
+		key, err := storage.Get(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, krot.KeyStateRevoked, key.State)
+	})
+
+	t.Run("Should reject invalid states", func(t *testing.T) {
+		err := storage.Add(ctx,
+			&krot.Key{ID: "4", Value: "4", Expires: expires},
+			&krot.Key{ID: "5", Value: "5", Expires: expires, State: krot.KeyState(42)},
+		)
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+
+		// Nothing is written, and the stored keys stay readable.
+		_, err = storage.Get(ctx, "4")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+
+		_, err = storage.Get(ctx, "5")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+
+	t.Run("Should list keys", func(t *testing.T) {
+		keys, err := storage.(krot.KeyLister).List(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+
+		keys, err = storage.(krot.KeyLister).List(ctx, &krot.KeyListOptions{IncludeExpired: true, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, "3", keys[0].ID)
+	})
+
+	t.Run("Should clear expired keys", func(t *testing.T) {
+		assert.NoError(t, storage.ClearDeprecated(ctx))
+
+		_, err := storage.Get(ctx, "3")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+	})
+
+	t.Run("Should delete keys", func(t *testing.T) {
+		assert.NoError(t, storage.Delete(ctx, "1", "unknown"))
+
+		_, err := storage.Get(ctx, "1")
+		assert.ErrorIs(t, err, krot.ErrKeyNotFound)
+
+		_, err = storage.Get(ctx, "2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should erase keys", func(t *testing.T) {
+		assert.NoError(t, storage.Erase(ctx))
+
+		keys, err := storage.(krot.KeyLister).List(ctx, &krot.KeyListOptions{IncludeExpired: true})
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Should reject an unsupported dialect", func(t *testing.T) {
+		err := krot.MigrateSQLKeyStorage(ctx, db, krot.SQLDialect(42))
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+	})
+}
+
+func TestRotatorWithSQLKeyStorage(t *testing.T) {
+	clock := krottest.NewClock(time.Now())
+
+	settings := krot.DefaultRotatorSettings()
+	settings.AutoClearExpiredKeys = false
+	settings.Clock = clock
+
+	rotator, err := krot.NewWithSettings(settings)
+	require.NoError(t, err)
+	require.NoError(t, rotator.SetStorage(krot.NewSQLKeyStorage(newSQLiteDB(t), krot.SQLDialectSQLite)))
+
+	assert.NoError(t, rotator.Rotate())
+	first, err := rotator.GetKey()
+	require.NoError(t, err)
+
+	clock.Advance(settings.RotationInterval)
+	assert.NoError(t, rotator.Rotate())
+
+	retired, err := rotator.GetKeyByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, krot.KeyStateRetiring, retired.State)
+	assert.Equal(t, first.Value, retired.Value)
+
+	keys, err := rotator.Keys(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2*settings.RotationKeyCount)
+}
@@ -0,0 +1,134 @@
+package krot_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+func TestRotatorStateStore(t *testing.T) {
+	ctx := context.Background()
+
+	stores := map[string]func(t *testing.T) krot.RotatorStateStore{
+		"InMemory": func(t *testing.T) krot.RotatorStateStore {
+			return krot.NewRotatorStateStore()
+		},
+		"File": func(t *testing.T) krot.RotatorStateStore {
+			return krot.NewFileRotatorStateStore(filepath.Join(t.TempDir(), "rotator.json"))
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			t.Run("Should return ErrStateNotFound before the first save", func(t *testing.T) {
+				_, err := store.Load(ctx)
+				assert.ErrorIs(t, err, krot.ErrStateNotFound)
+			})
+
+			t.Run("Should load the saved snapshot", func(t *testing.T) {
+				now := time.Now().UTC().Truncate(time.Second)
+				snapshot := &krot.RotatorSnapshot{
+					ActiveKeyIDs:   []string{"a", "b"},
+					PendingKeyIDs:  []string{"c"},
+					RotatedAt:      now,
+					ScheduledAt:    now.Add(time.Hour),
+					ProviderCursor: 1,
+				}
+
+				assert.NoError(t, store.Save(ctx, snapshot))
+
+				loaded, err := store.Load(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, snapshot, loaded)
+			})
+
+			t.Run("Should reject a nil snapshot", func(t *testing.T) {
+				assert.ErrorIs(t, store.Save(ctx, nil), krot.ErrInvalidArgument)
+			})
+		})
+	}
+}
+
+func TestRotatorResume(t *testing.T) {
+	ctx := context.Background()
+
+	clock := krottest.NewClock(time.Now())
+	storage := krot.NewKeyStorageWithClock(clock)
+	store := krot.NewRotatorStateStore()
+
+	newRotator := func(t *testing.T) *krot.Rotator {
+		settings := krot.DefaultRotatorSettings()
+		settings.AutoClearExpiredKeys = false
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetStorage(storage))
+		assert.NoError(t, rotator.SetStateStore(store))
+		return rotator
+	}
+
+	countKeys := func(t *testing.T) int {
+		keys, err := storage.(krot.KeyLister).List(ctx, nil)
+		assert.NoError(t, err)
+		return len(keys)
+	}
+
+	first := newRotator(t)
+	assert.NoError(t, first.Start())
+	first.Stop()
+
+	count := countKeys(t)
+
+	t.Run("Should resume with the saved keys", func(t *testing.T) {
+		rotator := newRotator(t)
+		assert.NoError(t, rotator.Start())
+		defer rotator.Stop()
+
+		assert.Equal(t, count, countKeys(t))
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Contains(t, key.ID, first.ID())
+	})
+
+	t.Run("Should rotate when a saved key was revoked", func(t *testing.T) {
+		snapshot, err := store.Load(ctx)
+		assert.NoError(t, err)
+
+		assert.NoError(t, first.Revoke(ctx, snapshot.ActiveKeyIDs[0]))
+
+		rotator := newRotator(t)
+		assert.NoError(t, rotator.Start())
+		rotator.Stop()
+
+		assert.Equal(t, 2*count, countKeys(t))
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Contains(t, key.ID, rotator.ID())
+	})
+
+	t.Run("Should rotate when the next rotation is due", func(t *testing.T) {
+		clock.Advance(first.RotationInterval())
+
+		rotator := newRotator(t)
+		assert.NoError(t, rotator.Start())
+		rotator.Stop()
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Contains(t, key.ID, rotator.ID())
+	})
+
+	t.Run("Should reject a nil state store", func(t *testing.T) {
+		assert.ErrorIs(t, krot.New().SetStateStore(nil), krot.ErrInvalidArgument)
+	})
+}
@@ -106,3 +106,83 @@ func TestInMemoryKeyStorage(t *testing.T) {
 		assert.Nil(t, key)
 	})
 }
+
+func TestInMemoryKeyStorageList(t *testing.T) {
+	storage := krot.NewKeyStorage()
+	lister := storage.(krot.KeyLister)
+
+	now := time.Now()
+	storage.Add(
+		context.Background(),
+		&krot.Key{ID: "1", Value: "1", Expires: now.Add(time.Hour)},
+		&krot.Key{ID: "2", Value: "2", Expires: now.Add(2 * time.Hour)},
+		&krot.Key{ID: "3", Value: "3", Expires: now.Add(3 * time.Hour)},
+		&krot.Key{ID: "4", Value: "4", Expires: now.Add(-time.Hour)},
+	)
+
+	ids := func(keys []*krot.Key) []string {
+		ids := make([]string, len(keys))
+		for i, key := range keys {
+			ids[i] = key.ID
+		}
+		return ids
+	}
+
+	t.Run("Should list non-expired keys by default", func(t *testing.T) {
+		keys, err := lister.List(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3"}, ids(keys))
+	})
+
+	t.Run("Should include expired keys", func(t *testing.T) {
+		keys, err := lister.List(context.Background(), &krot.KeyListOptions{IncludeExpired: true})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"4", "1", "2", "3"}, ids(keys))
+	})
+
+	t.Run("Should page keys", func(t *testing.T) {
+		keys, err := lister.List(context.Background(), &krot.KeyListOptions{Offset: 1, Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2"}, ids(keys))
+
+		keys, err = lister.List(context.Background(), &krot.KeyListOptions{Offset: 3})
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Should filter keys by expiration", func(t *testing.T) {
+		keys, err := lister.List(context.Background(), &krot.KeyListOptions{
+			ExpiresAfter:  now.Add(90 * time.Minute),
+			ExpiresBefore: now.Add(150 * time.Minute),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2"}, ids(keys))
+	})
+}
+
+func TestInMemoryKeyStorageWatch(t *testing.T) {
+	ctx := context.Background()
+
+	storage := krot.NewKeyStorage()
+	changes, err := storage.(krot.KeyWatcher).Watch(ctx)
+	assert.NoError(t, err)
+
+	t.Run("Should notify purged keys", func(t *testing.T) {
+		assert.NoError(t, storage.Add(ctx, &krot.Key{ID: "1", Value: "1", Expires: time.Now().Add(-time.Minute)}))
+		<-changes
+
+		count, err := storage.(krot.KeyPurger).PurgeDeprecated(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("Should not notify empty purges", func(t *testing.T) {
+		<-changes
+
+		count, err := storage.(krot.KeyPurger).PurgeDeprecated(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Empty(t, changes)
+	})
+}
@@ -0,0 +1,98 @@
+package krot_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+)
+
+func TestTypedRotator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should return typed keys", func(t *testing.T) {
+		rotator, err := krot.NewTyped(krot.Typed[*krot.KeyPair](krot.NewEd25519KeyGenerator()))
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Equal(t, krot.AlgorithmEdDSA, key.Value.Algorithm)
+		assert.True(t, key.CanSign())
+
+		byID, err := rotator.GetKeyByID(key.ID)
+		assert.NoError(t, err)
+		assert.Same(t, key.Value, byID.Value)
+
+		keys, err := rotator.Keys(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, rotator.RotationKeyCount())
+	})
+
+	t.Run("Should accept generator functions", func(t *testing.T) {
+		generator := krot.TypedKeyGeneratorFunc[[]byte](func() ([]byte, error) {
+			secret := make([]byte, 32)
+			_, err := rand.Read(secret)
+			return secret, err
+		})
+
+		rotator, err := krot.NewTypedWithSettings[[]byte](generator, krot.DefaultRotatorSettings())
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		key, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Len(t, key.Value, 32)
+	})
+
+	t.Run("Should report generator errors", func(t *testing.T) {
+		failure := errors.New("generator failure")
+		rotator, err := krot.NewTyped(krot.TypedKeyGeneratorFunc[string](func() (string, error) {
+			return "", failure
+		}))
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, rotator.Rotate(), failure)
+	})
+
+	t.Run("Should reject keys of another type", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+
+		untyped := krot.New()
+		assert.NoError(t, untyped.SetStorage(storage))
+		assert.NoError(t, untyped.Rotate())
+
+		key, err := untyped.GetKey()
+		assert.NoError(t, err)
+
+		rotator, err := krot.NewTyped(krot.Typed[*krot.KeyPair](krot.NewEd25519KeyGenerator()))
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetStorage(storage))
+
+		_, err = rotator.GetKeyByID(key.ID)
+		assert.ErrorIs(t, err, krot.ErrKeyTypeMismatch)
+
+		_, err = krot.NewTypedKey[int](key)
+		assert.ErrorIs(t, err, krot.ErrKeyTypeMismatch)
+	})
+
+	t.Run("Should reject generated values of another type", func(t *testing.T) {
+		rotator, err := krot.NewTyped(krot.Typed[*krot.KeyPair](krot.NewKeyGenerator(krot.KeySize256)))
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, rotator.Rotate(), krot.ErrKeyTypeMismatch)
+	})
+
+	t.Run("Should reject a nil generator", func(t *testing.T) {
+		rotator, err := krot.NewTyped(krot.Typed[string](krot.NewKeyGenerator(krot.KeySize256)))
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, rotator.SetGenerator(nil), krot.ErrInvalidArgument)
+
+		_, err = krot.NewTyped[string](nil)
+		assert.ErrorIs(t, err, krot.ErrInvalidArgument)
+	})
+}
@@ -0,0 +1,347 @@
+package krot_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaori96/krot"
+	"github.com/zhaori96/krot/krottest"
+)
+
+// countingLister counts the listings of the keys it holds.
+type countingLister struct {
+	krot.KeyStorage
+	lists atomic.Int64
+}
+
+func (s *countingLister) List(ctx context.Context, options *krot.KeyListOptions) ([]*krot.Key, error) {
+	s.lists.Add(1)
+	return s.KeyStorage.(krot.KeyLister).List(ctx, options)
+}
+
+func TestMaxKeyUses(t *testing.T) {
+	ctx := context.Background()
+
+	newRotator := func(t *testing.T, maxKeyUses int) (*krot.Rotator, krot.KeyStorage) {
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationKeyCount = 2
+		settings.MaxKeyUses = maxKeyUses
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+
+		storage := krot.NewKeyStorage()
+		assert.NoError(t, rotator.SetStorage(storage))
+		assert.NoError(t, rotator.Rotate())
+
+		return rotator, storage
+	}
+
+	t.Run("Should retire keys after their last use", func(t *testing.T) {
+		rotator, storage := newRotator(t, 3)
+
+		uses := map[string]int{}
+		for i := 0; i < 3; i++ {
+			key, err := rotator.GetKey()
+			assert.NoError(t, err)
+			uses[key.ID]++
+		}
+
+		for i := 0; i < 3; i++ {
+			key, err := rotator.GetKeyFor("user")
+			assert.NoError(t, err)
+			uses[key.ID]++
+		}
+
+		assert.Len(t, uses, 2)
+		for id, count := range uses {
+			assert.Equal(t, 3, count, id)
+
+			key, err := storage.Get(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, krot.KeyStateRetiring, key.State)
+			assert.False(t, key.RetiredAt.IsZero())
+
+			// Retired keys still verify.
+			_, err = rotator.GetKeyByID(id)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("Should rotate once every key is exhausted", func(t *testing.T) {
+		rotator, _ := newRotator(t, 1)
+
+		rotations := 0
+		rotator.AfterRotation(func(*krot.Rotator) { rotations++ })
+
+		seen := map[string]bool{}
+		for i := 0; i < 10; i++ {
+			key, err := rotator.GetKey()
+			assert.NoError(t, err)
+			assert.False(t, seen[key.ID], "key %s was used twice", key.ID)
+			seen[key.ID] = true
+		}
+
+		assert.Equal(t, 5, rotations)
+	})
+
+	t.Run("Should never return keys over their budget", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationKeyCount = 1
+		settings.MaxKeyUses = 1
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		var mutex sync.Mutex
+		seen := map[string]bool{}
+
+		var wait sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wait.Add(1)
+			go func() {
+				defer wait.Done()
+
+				key, err := rotator.GetKey()
+				if errors.Is(err, krot.ErrKeyExhausted) {
+					return
+				}
+
+				assert.NoError(t, err)
+				if key == nil {
+					return
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				assert.False(t, seen[key.ID], "key %s was used twice", key.ID)
+				seen[key.ID] = true
+			}()
+		}
+
+		wait.Wait()
+		assert.NotEmpty(t, seen)
+	})
+
+	t.Run("Should replace exhausted keys despite cancelled contexts", func(t *testing.T) {
+		rotator, _ := newRotator(t, 1)
+
+		cancellable, cancel := context.WithCancel(ctx)
+		rotator.BeforeRotation(func(*krot.Rotator) { cancel() })
+
+		first, err := rotator.GetKeyContext(cancellable)
+		assert.NoError(t, err)
+
+		last, err := rotator.GetKeyContext(cancellable)
+		assert.NoError(t, err)
+
+		next, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.NotEqual(t, first.ID, next.ID)
+		assert.NotEqual(t, last.ID, next.ID)
+	})
+
+	t.Run("Should retry failed replacements", func(t *testing.T) {
+		var failing atomic.Bool
+
+		clock := krottest.NewClock(time.Now())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationKeyCount = 1
+		settings.MaxKeyUses = 1
+		settings.Clock = clock
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.SetGenerator(krot.Untyped(krot.TypedKeyGeneratorFunc[string](func() (string, error) {
+			if failing.Load() {
+				return "", errors.New("generator failure")
+			}
+
+			return "secret", nil
+		}))))
+		assert.NoError(t, rotator.Rotate())
+
+		rotations := 0
+		rotator.AfterRotation(func(*krot.Rotator) { rotations++ })
+
+		failing.Store(true)
+
+		exhausted, err := rotator.GetKey()
+		assert.NoError(t, err)
+
+		// The exhausted key is not returned again, and retries are spaced out.
+		for i := 0; i < 10; i++ {
+			_, err = rotator.GetKey()
+			assert.ErrorIs(t, err, krot.ErrKeyExhausted)
+		}
+
+		failing.Store(false)
+
+		_, err = rotator.GetKey()
+		assert.ErrorIs(t, err, krot.ErrKeyExhausted)
+		assert.Zero(t, rotations)
+
+		clock.Advance(time.Second)
+
+		next, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.NotEqual(t, exhausted.ID, next.ID)
+		assert.NotZero(t, rotations)
+	})
+
+	t.Run("Should wait for the writer in Follower mode", func(t *testing.T) {
+		clock := krottest.NewClock(time.Now())
+		storage := &countingLister{KeyStorage: krot.NewKeyStorageWithClock(clock)}
+
+		writer := krot.New()
+		assert.NoError(t, writer.SetStorage(storage))
+		assert.NoError(t, writer.Rotate())
+
+		settings := krot.DefaultRotatorSettings()
+		settings.Follower = true
+		settings.RotationKeyCount = 1
+		settings.MaxKeyUses = 1
+		settings.Clock = clock
+
+		follower, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, follower.SetStorage(storage))
+		assert.NoError(t, follower.Rotate())
+
+		used := map[string]bool{}
+		for i := 0; i < writer.RotationKeyCount(); i++ {
+			key, err := follower.GetKey()
+			assert.NoError(t, err)
+			assert.False(t, used[key.ID], "key %s was used twice", key.ID)
+			used[key.ID] = true
+
+			// Followers never retire the writer's keys.
+			stored, err := storage.Get(ctx, key.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, krot.KeyStateActive, stored.State)
+		}
+
+		lists := storage.lists.Load()
+		for i := 0; i < 10; i++ {
+			_, err := follower.GetKey()
+			assert.ErrorIs(t, err, krot.ErrKeyExhausted)
+		}
+		assert.Equal(t, lists, storage.lists.Load())
+
+		assert.NoError(t, writer.Rotate())
+		clock.Advance(time.Second)
+
+		key, err := follower.GetKey()
+		assert.NoError(t, err)
+		assert.False(t, used[key.ID])
+	})
+
+	t.Run("Should ask the leader to rotate instead of retiring keys", func(t *testing.T) {
+		storage := krot.NewKeyStorage()
+		lock := krot.NewRotationLock()
+
+		newCoordinated := func(t *testing.T) *krot.Rotator {
+			settings := krot.DefaultRotatorSettings()
+			settings.RotationKeyCount = 2
+			settings.MaxKeyUses = 1
+
+			rotator, err := krot.NewWithSettings(settings)
+			assert.NoError(t, err)
+			assert.NoError(t, rotator.SetStorage(storage))
+			assert.NoError(t, rotator.SetRotationLock(lock))
+			return rotator
+		}
+
+		leader, follower := newCoordinated(t), newCoordinated(t)
+		assert.NoError(t, leader.Start())
+		defer leader.Stop()
+
+		assert.NoError(t, follower.Rotate())
+		assert.False(t, follower.Leader())
+
+		events := leader.Subscribe(ctx)
+
+		used := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			key, err := follower.GetKey()
+			assert.NoError(t, err)
+			used[key.ID] = true
+		}
+
+		for id := range used {
+			key, err := storage.Get(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, krot.KeyStateActive, key.State)
+		}
+
+		select {
+		case event := <-events:
+			rotated := event.(krot.KeysRotated)
+			assert.Len(t, rotated.Added, leader.RotationKeyCount())
+		case <-time.After(time.Second):
+			t.Fatal("the leader did not rotate")
+		}
+
+		assert.NoError(t, follower.Rotate())
+
+		key, err := follower.GetKey()
+		assert.NoError(t, err)
+		assert.False(t, used[key.ID])
+	})
+
+	t.Run("Should not limit uses by default", func(t *testing.T) {
+		rotator, _ := newRotator(t, 0)
+
+		before, err := rotator.Keys(ctx)
+		assert.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			_, err := rotator.GetKey()
+			assert.NoError(t, err)
+		}
+
+		after, err := rotator.Keys(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, after, len(before))
+	})
+
+	t.Run("Should reject negative limits", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.MaxKeyUses = -1
+
+		_, err := krot.NewWithSettings(settings)
+		assert.ErrorIs(t, err, krot.ErrInvalidMaxKeyUses)
+	})
+
+	t.Run("Should forget the uses of rotated keys", func(t *testing.T) {
+		settings := krot.DefaultRotatorSettings()
+		settings.RotationKeyCount = 1
+		settings.MaxKeyUses = 2
+
+		rotator, err := krot.NewWithSettings(settings)
+		assert.NoError(t, err)
+		assert.NoError(t, rotator.Rotate())
+
+		first, err := rotator.GetKey()
+		assert.NoError(t, err)
+
+		assert.NoError(t, rotator.Rotate())
+
+		second, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.NotEqual(t, first.ID, second.ID)
+
+		// The new key has a budget of its own.
+		again, err := rotator.GetKey()
+		assert.NoError(t, err)
+		assert.Equal(t, second.ID, again.ID)
+	})
+}
@@ -0,0 +1,226 @@
+package krot
+
+import (
+	"context"
+	"fmt"
+)
+
+// TypedKey is a Key whose value is statically typed. It embeds the untyped Key,
+// so its ID, expiration, state and methods are available as well, while its
+// Value field shadows the untyped one.
+type TypedKey[T any] struct {
+	*Key
+
+	// Value is the key material.
+	Value T
+}
+
+// NewTypedKey returns the typed view of the given key. If the key value is not
+// of type T, it returns an ErrKeyTypeMismatch.
+func NewTypedKey[T any](key *Key) (*TypedKey[T], error) {
+	if key == nil {
+		return nil, fmt.Errorf("%w: key cannot be nil", ErrInvalidArgument)
+	}
+
+	value, ok := key.Value.(T)
+	if !ok {
+		return nil, fmt.Errorf("%w: key %s holds a %T, not a %T", ErrKeyTypeMismatch, key.ID, key.Value, *new(T))
+	}
+
+	return &TypedKey[T]{Key: key, Value: value}, nil
+}
+
+// TypedKeyGenerator is a KeyGenerator whose keys are statically typed.
+type TypedKeyGenerator[T any] interface {
+	// Generate creates a new key. If the key cannot be generated, it returns an error.
+	//
+	//     key, err := generator.Generate()
+	//     if err != nil {
+	//         log.Fatal(err)
+	//     }
+	Generate() (T, error)
+}
+
+// TypedKeyGeneratorFunc is a function used as a TypedKeyGenerator.
+//
+//	generator := krot.TypedKeyGeneratorFunc[[]byte](func() ([]byte, error) {
+//	    secret := make([]byte, 32)
+//	    _, err := rand.Read(secret)
+//	    return secret, err
+//	})
+type TypedKeyGeneratorFunc[T any] func() (T, error)
+
+// Generate calls the function.
+func (f TypedKeyGeneratorFunc[T]) Generate() (T, error) {
+	return f()
+}
+
+type typedKeyGenerator[T any] struct {
+	generator KeyGenerator
+}
+
+// Typed returns the typed view of an untyped KeyGenerator, such as the ones
+// created by NewKeyGenerator (string) or NewECDSAKeyGenerator (*KeyPair).
+// Generated values that are not of type T return an ErrKeyTypeMismatch.
+//
+//	generator := krot.Typed[*krot.KeyPair](krot.NewEd25519KeyGenerator())
+func Typed[T any](generator KeyGenerator) TypedKeyGenerator[T] {
+	return &typedKeyGenerator[T]{generator: generator}
+}
+
+func (g *typedKeyGenerator[T]) Generate() (T, error) {
+	var zero T
+
+	value, err := g.generator.Generate()
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: generated a %T, not a %T", ErrKeyTypeMismatch, value, zero)
+	}
+
+	return typed, nil
+}
+
+type untypedKeyGenerator[T any] struct {
+	generator TypedKeyGenerator[T]
+}
+
+// Untyped returns the untyped view of a TypedKeyGenerator, which can be set on
+// a Rotator.
+func Untyped[T any](generator TypedKeyGenerator[T]) KeyGenerator {
+	return &untypedKeyGenerator[T]{generator: generator}
+}
+
+func (g *untypedKeyGenerator[T]) Generate() (any, error) {
+	return g.generator.Generate()
+}
+
+// TypedRotator is a Rotator whose keys are statically typed: the type of the
+// key material flows from its TypedKeyGenerator to GetKey, instead of being
+// asserted from Key.Value by every caller.
+//
+// It embeds the untyped Rotator, whose other methods are available as well.
+// Keys whose value is not of type T, for instance because the storage was
+// shared with a Rotator using another generator, return an ErrKeyTypeMismatch.
+type TypedRotator[T any] struct {
+	*Rotator
+}
+
+// NewTyped returns a newly initialized TypedRotator with default settings and
+// storage, generating its keys with the given generator.
+// If the generator is nil, it returns an ErrInvalidArgument.
+//
+//	rotator, err := krot.NewTyped(krot.Typed[string](krot.NewKeyGenerator(krot.KeySize256)))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	rotator.Start()
+//	defer rotator.Stop()
+//
+//	key, err := rotator.GetKey()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	secret := []byte(key.Value) // key.Value is a string
+func NewTyped[T any](generator TypedKeyGenerator[T]) (*TypedRotator[T], error) {
+	rotator := &TypedRotator[T]{Rotator: New()}
+	if err := rotator.SetGenerator(generator); err != nil {
+		return nil, err
+	}
+
+	return rotator, nil
+}
+
+// NewTypedWithSettings returns a newly initialized TypedRotator with the given
+// settings, generating its keys with the given generator.
+func NewTypedWithSettings[T any](generator TypedKeyGenerator[T], settings *RotatorSettings) (*TypedRotator[T], error) {
+	untyped, err := NewWithSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	rotator := &TypedRotator[T]{Rotator: untyped}
+	if err := rotator.SetGenerator(generator); err != nil {
+		return nil, err
+	}
+
+	return rotator, nil
+}
+
+// SetGenerator sets the generator of the TypedRotator's keys.
+// If the TypedRotator is running, it panics.
+// If the generator is nil, it returns an ErrInvalidArgument.
+func (r *TypedRotator[T]) SetGenerator(generator TypedKeyGenerator[T]) error {
+	if generator == nil {
+		return fmt.Errorf("%w: generator cannot be nil", ErrInvalidArgument)
+	}
+
+	return r.Rotator.SetGenerator(Untyped(generator))
+}
+
+// GetKey works like Rotator.GetKey, returning a typed key.
+func (r *TypedRotator[T]) GetKey() (*TypedKey[T], error) {
+	return r.GetKeyContext(context.Background())
+}
+
+// GetKeyContext works like Rotator.GetKeyContext, returning a typed key.
+func (r *TypedRotator[T]) GetKeyContext(ctx context.Context) (*TypedKey[T], error) {
+	key, err := r.Rotator.GetKeyContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTypedKey[T](key)
+}
+
+// GetKeyFor works like Rotator.GetKeyFor, returning a typed key.
+func (r *TypedRotator[T]) GetKeyFor(subject string) (*TypedKey[T], error) {
+	return r.GetKeyForContext(context.Background(), subject)
+}
+
+// GetKeyForContext works like Rotator.GetKeyForContext, returning a typed key.
+func (r *TypedRotator[T]) GetKeyForContext(ctx context.Context, subject string) (*TypedKey[T], error) {
+	key, err := r.Rotator.GetKeyForContext(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTypedKey[T](key)
+}
+
+// GetKeyByID works like Rotator.GetKeyByID, returning a typed key.
+func (r *TypedRotator[T]) GetKeyByID(id string) (*TypedKey[T], error) {
+	return r.GetKeyByIDContext(context.Background(), id)
+}
+
+// GetKeyByIDContext works like Rotator.GetKeyByIDContext, returning a typed key.
+func (r *TypedRotator[T]) GetKeyByIDContext(ctx context.Context, id string) (*TypedKey[T], error) {
+	key, err := r.Rotator.GetKeyByIDContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTypedKey[T](key)
+}
+
+// Keys works like Rotator.Keys, returning typed keys.
+func (r *TypedRotator[T]) Keys(ctx context.Context) ([]*TypedKey[T], error) {
+	keys, err := r.Rotator.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	typed := make([]*TypedKey[T], len(keys))
+	for i, key := range keys {
+		if typed[i], err = NewTypedKey[T](key); err != nil {
+			return nil, err
+		}
+	}
+
+	return typed, nil
+}